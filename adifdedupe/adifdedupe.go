@@ -4,7 +4,6 @@ import (
 	"flag"
 	"fmt"
 	"github.com/jj1bdx/adifparser"
-	"io"
 	"os"
 )
 
@@ -36,11 +35,9 @@ func main() {
 	}
 
 	reader := adifparser.NewDedupeADIFReader(fp)
-	for record, err := reader.ReadRecord(); record != nil || err != nil; record, err = reader.ReadRecord() {
+	for record, err := range reader.All() {
 		if err != nil {
-			if err != io.EOF {
-				fmt.Fprint(os.Stderr, err)
-			}
+			fmt.Fprint(os.Stderr, err)
 			break
 		}
 		writer.WriteRecord(record)
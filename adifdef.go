@@ -75,6 +75,8 @@ func init() {
 	addField("band_rx", ADIFString)
 	addField("check", ADIFString)
 	addField("class", ADIFString)
+	addField("clublog_qso_upload_date", ADIFDate)
+	addField("clublog_qso_upload_status", ADIFString)
 	addField("cnty", ADIFString)
 	addField("comment", ADIFString)
 	addField("cont", ADIFString)
@@ -136,6 +138,8 @@ func init() {
 	addField("precedence", ADIFString)
 	addField("prop_mode", ADIFString)
 	addField("public_key", ADIFString)
+	addField("qrzcom_qso_upload_date", ADIFDate)
+	addField("qrzcom_qso_upload_status", ADIFString)
 	addField("qslmsg", ADIFString)
 	addField("qslrdate", ADIFDate)
 	addField("qslsdate", ADIFDate)
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/jj1bdx/adifparser"
+	"os"
+)
+
+func main() {
+	var infile = flag.String("infile", "", "Input file.")
+	var outfile = flag.String("outfile", "", "Output file.")
+	var expr = flag.String("expr", "", "Filter expression.")
+
+	flag.Parse()
+
+	if *infile == "" {
+		fmt.Fprint(os.Stderr, "Need infile.\n")
+		return
+	}
+	if *expr == "" {
+		fmt.Fprint(os.Stderr, "Need expr.\n")
+		return
+	}
+
+	match, err := adifparser.CompileQuery(*expr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return
+	}
+
+	fp, err := os.Open(*infile)
+	if err != nil {
+		fmt.Fprint(os.Stderr, err)
+		return
+	}
+	defer fp.Close()
+
+	var writer adifparser.ADIFWriter
+	var writefp *os.File
+	if *outfile != "" {
+		writefp, err = os.Create(*outfile)
+		if err != nil {
+			fmt.Fprint(os.Stderr, err)
+			return
+		}
+		writer = adifparser.NewADIFWriter(writefp)
+	} else {
+		writefp = nil
+		writer = adifparser.NewADIFWriter(os.Stdout)
+	}
+
+	reader := adifparser.NewFilterADIFReader(adifparser.NewADIFReader(fp), match)
+	for record, err := range reader.All() {
+		if err != nil {
+			fmt.Fprint(os.Stderr, err)
+			break
+		}
+		writer.WriteRecord(record)
+	}
+
+	writer.Flush()
+
+	if writefp != nil {
+		writefp.Close()
+	}
+}
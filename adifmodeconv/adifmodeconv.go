@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/jj1bdx/adifparser"
+	"os"
+)
+
+func main() {
+	var infile = flag.String("infile", "", "Input file.")
+	var outfile = flag.String("outfile", "", "Output file.")
+	var downgrade = flag.Bool("downgrade", false, "Convert modes to ADIF 2 instead of ADIF 3.")
+
+	flag.Parse()
+
+	if *infile == "" {
+		fmt.Fprint(os.Stderr, "Need infile.\n")
+		return
+	}
+
+	fp, err := os.Open(*infile)
+	if err != nil {
+		fmt.Fprint(os.Stderr, err)
+		return
+	}
+	defer fp.Close()
+
+	var writefp *os.File
+	out := os.Stdout
+	if *outfile != "" {
+		writefp, err = os.Create(*outfile)
+		if err != nil {
+			fmt.Fprint(os.Stderr, err)
+			return
+		}
+		out = writefp
+	}
+	writer := adifparser.NewADIFWriter(out)
+
+	var reader adifparser.ADIFReader
+	version := adifparser.ADIFVersion3
+	if *downgrade {
+		reader = adifparser.NewModeDowngradeADIFReader(adifparser.NewADIFReader(fp))
+		version = adifparser.ADIFVersion2
+	} else {
+		reader = adifparser.NewModeUpgradeADIFReader(adifparser.NewADIFReader(fp))
+	}
+	writer.SetComment(adifparser.ADIFVersionComment(
+		fmt.Sprintf("Converted from %s", *infile), version))
+
+	for record, err := range adifparser.AllRecords(reader) {
+		if err != nil {
+			fmt.Fprint(os.Stderr, err)
+			break
+		}
+		writer.WriteRecord(record)
+	}
+
+	writer.Flush()
+
+	if writefp != nil {
+		writefp.Close()
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"io"
 	"strconv"
+	"strings"
 )
 
 // Interface for ADIFReader
@@ -23,6 +24,14 @@ type baseADIFReader struct {
 	headerRead bool
 	// Version string of the adif file
 	version string
+	// Header fields
+	header map[string]string
+	// Text in the header outside the tags
+	headerComment string
+	// Whether <APP_LoTW_EOF> has been read
+	lotwEOF bool
+	// Whether the text following each field is captured as an annotation
+	captureAnnotations bool
 	// Record count
 	records int
 }
@@ -48,6 +57,8 @@ type elementData struct {
 	hasType bool
 	// Length of value bytes/string
 	valueLength int
+	// Text skipped before the tag
+	preceding string
 }
 
 func (ardr *baseADIFReader) ReadRecord() (ADIFRecord, error) {
@@ -58,6 +69,7 @@ func (ardr *baseADIFReader) ReadRecord() (ADIFRecord, error) {
 	}
 
 	foundeor := false
+	lastField := ""
 	for !foundeor {
 		element, err := ardr.readElement()
 		if err != nil {
@@ -66,13 +78,23 @@ func (ardr *baseADIFReader) ReadRecord() (ADIFRecord, error) {
 			}
 			return nil, err
 		}
+		if ardr.captureAnnotations && lastField != "" {
+			if annotation := cleanAnnotation(element.preceding); annotation != "" {
+				record.annotations[lastField] = annotation
+			}
+		}
+		lastField = ""
 		if element.name == "eor" && !element.hasValue {
 			foundeor = true
 			break
 		}
+		if element.name == "app_lotw_eof" && !element.hasValue {
+			ardr.lotwEOF = true
+		}
 		if element.hasValue {
 			// TODO: accomodate types
 			record.values[element.name] = element.value
+			lastField = element.name
 		}
 	}
 	// Successfully parsed the record
@@ -137,10 +159,17 @@ func (ardr *baseADIFReader) readHeader() {
 			// TODO: Log the error somewhere
 			return
 		}
+		ardr.headerComment += element.preceding
 		if element.name == "eoh" && !element.hasValue {
 			foundeoh = true
 			break
 		}
+		if element.hasValue {
+			if ardr.header == nil {
+				ardr.header = make(map[string]string)
+			}
+			ardr.header[element.name] = element.value
+		}
 		if element.name == "adif_ver" && element.hasValue {
 			ardr.version = element.value
 		}
@@ -153,6 +182,42 @@ func (ardr *baseADIFReader) RecordCount() int {
 	return ardr.records
 }
 
+// Capture the text following each field value as an annotation
+// (e.g. "<MY_STATE:2>CO // Colorado" has the annotation "Colorado")
+func (ardr *baseADIFReader) SetCaptureAnnotations(enable bool) {
+	ardr.captureAnnotations = enable
+}
+
+// Trim the spaces and the leading "//" of an annotation
+func cleanAnnotation(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "//") {
+		s = strings.TrimSpace(s[2:])
+	}
+	return s
+}
+
+// Get the text in the header outside the tags
+// The header is read first if not yet read
+func (ardr *baseADIFReader) HeaderComment() string {
+	if !ardr.headerRead {
+		ardr.readHeader()
+	}
+	return ardr.headerComment
+}
+
+// Get a header field value
+// The header is read first if not yet read
+func (ardr *baseADIFReader) HeaderValue(name string) (string, error) {
+	if !ardr.headerRead {
+		ardr.readHeader()
+	}
+	if v, ok := ardr.header[string(bStrictToLower([]byte(name)))]; ok {
+		return v, nil
+	}
+	return "", ErrNoSuchField
+}
+
 func (ardr *baseADIFReader) readElement() (*elementData, error) {
 	var c byte
 	var err error
@@ -161,6 +226,7 @@ func (ardr *baseADIFReader) readElement() (*elementData, error) {
 	var fieldtype byte
 	fieldlenstr := make([]byte, 0, 8)
 	var fieldlength int = 0
+	var skipped []byte
 
 	data := &elementData{}
 
@@ -173,7 +239,11 @@ func (ardr *baseADIFReader) readElement() (*elementData, error) {
 			return nil, err
 		}
 		foundopentag = c == '<'
+		if !foundopentag {
+			skipped = append(skipped, c)
+		}
 	}
+	data.preceding = string(skipped)
 
 	// Get field name
 	data.hasValue = false
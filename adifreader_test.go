@@ -477,3 +477,95 @@ func TestReadElement3(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestHeaderValue(t *testing.T) {
+	f, err := os.Open("testdata/lotw_new.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	reader := NewADIFReader(f)
+	if v, err := reader.HeaderValue("APP_LoTW_LASTQSL"); err != nil {
+		t.Fatal(err)
+	} else if v != "2021-01-07 03:42:14" {
+		t.Fatalf("Expected 2021-01-07 03:42:14, got %s", v)
+	}
+	if v, err := reader.HeaderValue("programid"); err != nil || v != "LoTW" {
+		t.Fatalf("Expected LoTW, got %s (%v)", v, err)
+	}
+	if _, err := reader.HeaderValue("adif_ver"); err != ErrNoSuchField {
+		t.Fatalf("Expected %v, got %v", ErrNoSuchField, err)
+	}
+	if _, err := reader.ReadRecord(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadRecordAnnotations(t *testing.T) {
+	f, err := os.Open("testdata/lotw.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	reader := NewADIFReader(f)
+	reader.SetCaptureAnnotations(true)
+	r, err := reader.ReadRecord()
+	if err != nil {
+		t.Fatal(err)
+	}
+	record, ok := r.(AnnotatedRecord)
+	if !ok {
+		t.Fatal("Record is not an AnnotatedRecord")
+	}
+	if v, err := record.GetAnnotation("CALL"); err != nil ||
+		v != "LotW ADIF now includes comments like this one." {
+		t.Fatalf("Unexpected CALL annotation %q (%v)", v, err)
+	}
+	// Comment just before <eor>
+	if v, err := record.GetAnnotation("qslrdate"); err != nil ||
+		v != "A comment ending a record is a special case." {
+		t.Fatalf("Unexpected QSLRDATE annotation %q (%v)", v, err)
+	}
+	if _, err := record.GetAnnotation("band"); err != ErrNoSuchField {
+		t.Fatalf("Expected %v, got %v", ErrNoSuchField, err)
+	}
+	if v, _ := record.GetValue("call"); v != "KD4LV" {
+		t.Fatalf("Expected KD4LV, got %q", v)
+	}
+	record.DeleteField("call")
+	if _, err := record.GetAnnotation("call"); err != ErrNoSuchField {
+		t.Fatalf("Annotation not deleted with the field")
+	}
+	record.SetValue("QSLRDATE", "20240101")
+	if _, err := record.GetAnnotation("qslrdate"); err != ErrNoSuchField {
+		t.Fatalf("Annotation not cleared by SetValue")
+	}
+}
+
+func TestReadRecordAnnotationsLOTWNew(t *testing.T) {
+	for _, capture := range []bool{false, true} {
+		f, err := os.Open("testdata/lotw_new.adi")
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader := NewADIFReader(f)
+		reader.SetCaptureAnnotations(capture)
+		record, err := reader.ReadRecord()
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := record.(AnnotatedRecord).GetAnnotation("my_state")
+		if capture && (err != nil || v != "Colorado") {
+			t.Fatalf("Expected Colorado, got %q (%v)", v, err)
+		}
+		if !capture && err != ErrNoSuchField {
+			t.Fatalf("Expected no annotation, got %q", v)
+		}
+		if v, _ := record.GetValue("my_state"); v != "CO" {
+			t.Fatalf("Expected CO, got %q", v)
+		}
+	}
+}
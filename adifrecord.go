@@ -25,9 +25,21 @@ type ADIFRecord interface {
 	DeleteField(string) (bool, error)
 }
 
+// ADIFRecord with the annotations following the field values
+// (e.g. the comments of LoTW reports); check with a type assertion
+type AnnotatedRecord interface {
+	ADIFRecord
+	// Get the annotation following a field value
+	GetAnnotation(string) (string, error)
+	// Set the annotation of a field
+	SetAnnotation(string, string)
+}
+
 // Internal implementation for ADIFRecord
 type baseADIFRecord struct {
 	values map[string]string
+	// Annotations by field name
+	annotations map[string]string
 }
 
 // Errors
@@ -37,6 +49,7 @@ var ErrNoSuchField = errors.New("no such field")
 func NewADIFRecord() *baseADIFRecord {
 	record := &baseADIFRecord{}
 	record.values = make(map[string]string)
+	record.annotations = make(map[string]string)
 	return record
 }
 
@@ -96,8 +109,11 @@ func (r *baseADIFRecord) GetValue(name string) (string, error) {
 }
 
 // Set a value
+// The annotation of the field is cleared
 func (r *baseADIFRecord) SetValue(name string, value string) {
-	r.values[strings.ToLower(name)] = value
+	name = strings.ToLower(name)
+	r.values[name] = value
+	delete(r.annotations, name)
 }
 
 // Get all of the present field names
@@ -115,7 +131,22 @@ func (r *baseADIFRecord) GetFields() []string {
 func (r *baseADIFRecord) DeleteField(name string) (bool, error) {
 	if _, ok := r.values[name]; ok {
 		delete(r.values, name)
+		delete(r.annotations, name)
 		return true, nil
 	}
 	return false, ErrNoSuchField
 }
+
+// Get the annotation following a field value
+// Annotations are only captured by readers with SetCaptureAnnotations
+func (r *baseADIFRecord) GetAnnotation(name string) (string, error) {
+	if v, ok := r.annotations[strings.ToLower(name)]; ok {
+		return v, nil
+	}
+	return "", ErrNoSuchField
+}
+
+// Set the annotation of a field
+func (r *baseADIFRecord) SetAnnotation(name string, annotation string) {
+	r.annotations[strings.ToLower(name)] = annotation
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/jj1bdx/adifparser"
+	"github.com/jj1bdx/adifparser/stats"
+	"io"
+	"os"
+)
+
+func main() {
+	var infile = flag.String("infile", "", "Input file.")
+	var outfile = flag.String("outfile", "", "Output file.")
+	var format = flag.String("format", "text", "Output format: text, csv or json.")
+	var top = flag.Int("top", 10, "Number of the top calls and DXCC entities (0 for all).")
+
+	flag.Parse()
+
+	if *infile == "" {
+		fmt.Fprint(os.Stderr, "Need infile.\n")
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "csv" && *format != "json" {
+		fmt.Fprint(os.Stderr, "Unknown format.\n")
+		os.Exit(1)
+	}
+
+	fp, err := os.Open(*infile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer fp.Close()
+
+	collector := stats.NewCollector()
+	reader := adifparser.NewADIFReader(fp)
+	for record, err := range reader.All() {
+		if err != nil {
+			// Do not write a partial report as a complete one
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		collector.Add(record)
+	}
+
+	var w io.Writer = os.Stdout
+	var writefp *os.File
+	if *outfile != "" {
+		writefp, err = os.Create(*outfile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		w = writefp
+	}
+
+	report := collector.Report()
+	switch *format {
+	case "csv":
+		err = report.WriteCSV(w, *top)
+	case "json":
+		err = report.WriteJSON(w, *top)
+	default:
+		err = report.WriteText(w, *top)
+	}
+	if writefp != nil {
+		if cerr := writefp.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
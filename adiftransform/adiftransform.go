@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/jj1bdx/adifparser"
+	"os"
+)
+
+func main() {
+	var infile = flag.String("infile", "", "Input file.")
+	var outfile = flag.String("outfile", "", "Output file.")
+	var rulesfile = flag.String("rules", "", "Transform rules file.")
+
+	flag.Parse()
+
+	if *infile == "" {
+		fmt.Fprint(os.Stderr, "Need infile.\n")
+		return
+	}
+	if *rulesfile == "" {
+		fmt.Fprint(os.Stderr, "Need rules.\n")
+		return
+	}
+
+	rulesfp, err := os.Open(*rulesfile)
+	if err != nil {
+		fmt.Fprint(os.Stderr, err)
+		return
+	}
+	rules, err := adifparser.LoadTransformRules(rulesfp)
+	rulesfp.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", *rulesfile, err)
+		return
+	}
+
+	fp, err := os.Open(*infile)
+	if err != nil {
+		fmt.Fprint(os.Stderr, err)
+		return
+	}
+	defer fp.Close()
+
+	var writer adifparser.ADIFWriter
+	var writefp *os.File
+	if *outfile != "" {
+		writefp, err = os.Create(*outfile)
+		if err != nil {
+			fmt.Fprint(os.Stderr, err)
+			return
+		}
+		writer = adifparser.NewADIFWriter(writefp)
+	} else {
+		writefp = nil
+		writer = adifparser.NewADIFWriter(os.Stdout)
+	}
+
+	reader := adifparser.NewTransformADIFReader(adifparser.NewADIFReader(fp), rules)
+	for record, err := range reader.All() {
+		if err != nil {
+			fmt.Fprint(os.Stderr, err)
+			break
+		}
+		writer.WriteRecord(record)
+	}
+
+	writer.Flush()
+
+	if writefp != nil {
+		writefp.Close()
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/jj1bdx/adifparser"
+	"os"
+)
+
+func main() {
+	var infile = flag.String("infile", "", "Input file.")
+	var call = flag.String("call", "", "Callsign.")
+	var band = flag.String("band", "", "Band.")
+	var freq = flag.String("freq", "", "Frequency in MHz (instead of band).")
+	var mode = flag.String("mode", "", "Mode.")
+	var dxcc = flag.String("dxcc", "", "DXCC entity code.")
+	var grid = flag.String("grid", "", "Grid square.")
+
+	flag.Parse()
+
+	if *infile == "" {
+		fmt.Fprint(os.Stderr, "Need infile.\n")
+		return
+	}
+	if *call == "" && *dxcc == "" && *grid == "" {
+		fmt.Fprint(os.Stderr, "Need call, dxcc or grid.\n")
+		return
+	}
+
+	fp, err := os.Open(*infile)
+	if err != nil {
+		fmt.Fprint(os.Stderr, err)
+		return
+	}
+	defer fp.Close()
+
+	idx, err := adifparser.BuildWorkedIndex(adifparser.NewADIFReader(fp))
+	if err != nil {
+		fmt.Fprint(os.Stderr, err)
+		return
+	}
+
+	qso := adifparser.NewADIFRecord()
+	for k, v := range map[string]string{"call": *call, "band": *band, "freq": *freq,
+		"mode": *mode, "dxcc": *dxcc, "gridsquare": *grid} {
+		if v != "" {
+			qso.SetValue(k, v)
+		}
+	}
+	result := idx.Check(qso)
+
+	if *call != "" {
+		fmt.Printf("Call %s: %v\n", *call, result.Call)
+		fmt.Printf("Call %s on band/mode: %v\n", *call, result.CallBandMode)
+	}
+	if *dxcc != "" {
+		fmt.Printf("DXCC %s: %v\n", *dxcc, result.DXCC)
+		fmt.Printf("DXCC %s on band: %v\n", *dxcc, result.DXCCBand)
+		fmt.Printf("DXCC %s on mode: %v\n", *dxcc, result.DXCCMode)
+	}
+	if *grid != "" {
+		fmt.Printf("Grid %s: %v\n", *grid, result.Grid)
+		fmt.Printf("Grid %s on band: %v\n", *grid, result.GridBand)
+	}
+}
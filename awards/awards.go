@@ -0,0 +1,111 @@
+// Package awards computes amateur radio award standings from ADIF records
+package awards
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jj1bdx/adifparser"
+)
+
+// Mode groups of the awards
+const (
+	ModeCW      = "CW"
+	ModePhone   = "PHONE"
+	ModeDigital = "DIGITAL"
+)
+
+// Modes counted as phone
+var phoneModes = map[string]bool{
+	"SSB": true, "AM": true, "FM": true, "DIGITALVOICE": true,
+	"USB": true, "LSB": true,
+}
+
+// Mode group of the record: CW, PHONE or DIGITAL
+// APP_LoTW_MODEGROUP is used if given; empty if the mode is unknown
+func ModeGroup(r adifparser.ADIFRecord) string {
+	if g, err := r.GetValue("app_lotw_modegroup"); err == nil {
+		switch strings.ToUpper(strings.TrimSpace(g)) {
+		case "CW":
+			return ModeCW
+		case "PHONE":
+			return ModePhone
+		case "DATA":
+			return ModeDigital
+		}
+	}
+	mode, _ := r.GetValue("mode")
+	mode = strings.ToUpper(strings.TrimSpace(mode))
+	switch {
+	case mode == "":
+		return ""
+	case mode == "CW":
+		return ModeCW
+	case phoneModes[mode]:
+		return ModePhone
+	}
+	return ModeDigital
+}
+
+// Status of the record
+func recordStatus(r adifparser.ADIFRecord) adifparser.WorkedStatus {
+	if adifparser.IsQSOConfirmed(r) {
+		return adifparser.Confirmed
+	}
+	return adifparser.Worked
+}
+
+// Raise the status in the map
+func raise(m map[string]adifparser.WorkedStatus, key string, s adifparser.WorkedStatus) {
+	if m[key] < s {
+		m[key] = s
+	}
+}
+
+// Worked and confirmed counts
+type Count struct {
+	Worked    int `json:"worked"`
+	Confirmed int `json:"confirmed"`
+}
+
+// Count the statuses of the map
+func countStatuses(m map[string]adifparser.WorkedStatus) Count {
+	var c Count
+	for _, s := range m {
+		if s >= adifparser.Worked {
+			c.Worked++
+		}
+		if s == adifparser.Confirmed {
+			c.Confirmed++
+		}
+	}
+	return c
+}
+
+// Bands sorted by frequency, unknown bands last
+func sortBands(bands []string) {
+	sort.Slice(bands, func(i, j int) bool {
+		bi, bj := adifparser.BandIndex(bands[i]), adifparser.BandIndex(bands[j])
+		if bi < 0 || bj < 0 {
+			if bi != bj {
+				return bj < 0
+			}
+			return bands[i] < bands[j]
+		}
+		return bi < bj
+	})
+}
+
+// Mode groups in the order of the tables
+var modeGroups = []string{ModeCW, ModePhone, ModeDigital}
+
+// Mark of the status in the tables
+func statusMark(s adifparser.WorkedStatus) string {
+	switch s {
+	case adifparser.Confirmed:
+		return "C"
+	case adifparser.Worked:
+		return "W"
+	}
+	return "-"
+}
@@ -0,0 +1,328 @@
+package awards
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jj1bdx/adifparser"
+)
+
+// DXCC Challenge bands
+var ChallengeBands = []string{
+	"160m", "80m", "40m", "30m", "20m", "17m", "15m", "12m", "10m", "6m",
+}
+
+// Status of a DXCC entity
+type DXCCEntity struct {
+	// DXCC entity code
+	DXCC string `json:"dxcc"`
+	// COUNTRY of the first QSO with the entity
+	Country string                             `json:"country,omitempty"`
+	Deleted bool                               `json:"deleted,omitempty"`
+	Status  adifparser.WorkedStatus            `json:"status"`
+	Bands   map[string]adifparser.WorkedStatus `json:"bands"`
+	Modes   map[string]adifparser.WorkedStatus `json:"modes"`
+	// Whether DXCC credit is granted
+	Granted bool `json:"granted,omitempty"`
+}
+
+// DXCC standings
+// The counts except Deleted are of the current entities
+type DXCCReport struct {
+	// Mixed
+	Total Count `json:"total"`
+	// Deleted entities
+	Deleted Count `json:"deleted"`
+	// Entities with DXCC credit granted
+	Granted int `json:"granted"`
+	// Band slots on the Challenge bands
+	Challenge Count `json:"challenge"`
+	// By band and by mode group
+	Bands map[string]Count `json:"bands"`
+	Modes map[string]Count `json:"modes"`
+	// Entities in the code order
+	Entities []*DXCCEntity `json:"entities"`
+	// QSOs without DXCC
+	Skipped int `json:"skipped"`
+}
+
+// Confirmed slot without the credit granted
+type UncreditedSlot struct {
+	// Award of the credit: CreditDXCC, CreditDXCCBand or CreditDXCCMode
+	Award string
+	DXCC  string
+	// Band for CreditDXCCBand, mode group for CreditDXCCMode
+	Band string
+	Mode string
+	// A confirmed QSO of the slot
+	Record adifparser.ADIFRecord
+}
+
+// Confirmed QSOs and the credit of a slot
+type dxccSlot struct {
+	confirmed adifparser.ADIFRecord
+	granted   bool
+}
+
+// DXCC award tracker
+type dxccTracker struct {
+	entities map[string]*DXCCEntity
+	// Deleted entities given by SetDeletedEntities
+	deleted map[string]bool
+	// Slots by award and key
+	slots   map[string]map[string]*dxccSlot
+	skipped int
+}
+
+// Create a new DXCC tracker
+// Deleted entities are taken from APP_LoTW_DXCC_ENTITY_STATUS of the records
+// and SetDeletedEntities
+func NewDXCCTracker() *dxccTracker {
+	t := &dxccTracker{}
+	t.entities = make(map[string]*DXCCEntity)
+	t.deleted = make(map[string]bool)
+	t.slots = make(map[string]map[string]*dxccSlot)
+	for _, award := range []string{adifparser.CreditDXCC,
+		adifparser.CreditDXCCBand, adifparser.CreditDXCCMode} {
+		t.slots[award] = make(map[string]*dxccSlot)
+	}
+	return t
+}
+
+// Set the deleted entities by code
+func (t *dxccTracker) SetDeletedEntities(codes ...string) {
+	for _, c := range codes {
+		if c = adifparser.DXCCCode(c); c != "" {
+			t.deleted[c] = true
+			if e, ok := t.entities[c]; ok {
+				e.Deleted = true
+			}
+		}
+	}
+}
+
+// Update a slot with a QSO
+func (t *dxccTracker) updateSlot(award, key string, r adifparser.ADIFRecord,
+	status adifparser.WorkedStatus) {
+	slot, ok := t.slots[award][key]
+	if !ok {
+		slot = &dxccSlot{}
+		t.slots[award][key] = slot
+	}
+	if status == adifparser.Confirmed && slot.confirmed == nil {
+		slot.confirmed = r
+	}
+	if adifparser.IsCreditGranted(r, award) {
+		slot.granted = true
+	}
+}
+
+// Add a QSO
+func (t *dxccTracker) Add(r adifparser.ADIFRecord) {
+	code := adifparser.DXCCCode(adifparser.RecordValue(r, "dxcc"))
+	if code == "" {
+		t.skipped++
+		return
+	}
+	e, ok := t.entities[code]
+	if !ok {
+		e = &DXCCEntity{DXCC: code, Country: adifparser.RecordValue(r, "country"),
+			Deleted: t.deleted[code]}
+		e.Bands = make(map[string]adifparser.WorkedStatus)
+		e.Modes = make(map[string]adifparser.WorkedStatus)
+		t.entities[code] = e
+	}
+	if adifparser.IsDeletedEntity(r) {
+		e.Deleted = true
+	}
+	status := recordStatus(r)
+	if e.Status < status {
+		e.Status = status
+	}
+	t.updateSlot(adifparser.CreditDXCC, code, r, status)
+	if band := adifparser.RecordBand(r); band != "" {
+		raise(e.Bands, band, status)
+		t.updateSlot(adifparser.CreditDXCCBand, code+"/"+band, r, status)
+	}
+	if mode := ModeGroup(r); mode != "" {
+		raise(e.Modes, mode, status)
+		t.updateSlot(adifparser.CreditDXCCMode, code+"/"+mode, r, status)
+	}
+	if t.slots[adifparser.CreditDXCC][code].granted {
+		e.Granted = true
+	}
+}
+
+// Add all the QSOs of the reader
+func (t *dxccTracker) AddAll(rdr adifparser.ADIFReader) error {
+	for record, err := range adifparser.AllRecords(rdr) {
+		if err != nil {
+			return err
+		}
+		t.Add(record)
+	}
+	return nil
+}
+
+// Compute the standings
+// The report is a copy not updated by the subsequent Add calls
+func (t *dxccTracker) Report() *DXCCReport {
+	report := &DXCCReport{Skipped: t.skipped}
+	report.Bands = make(map[string]Count)
+	report.Modes = make(map[string]Count)
+	total := map[string]adifparser.WorkedStatus{}
+	deleted := map[string]adifparser.WorkedStatus{}
+	bands := map[string]map[string]adifparser.WorkedStatus{}
+	modes := map[string]map[string]adifparser.WorkedStatus{}
+	challenge := map[string]adifparser.WorkedStatus{}
+	isChallenge := map[string]bool{}
+	for _, b := range ChallengeBands {
+		isChallenge[b] = true
+	}
+
+	for code, e := range t.entities {
+		entity := *e
+		entity.Bands = copyStatuses(e.Bands)
+		entity.Modes = copyStatuses(e.Modes)
+		report.Entities = append(report.Entities, &entity)
+		if e.Deleted {
+			deleted[code] = e.Status
+			continue
+		}
+		total[code] = e.Status
+		if e.Granted {
+			report.Granted++
+		}
+		for b, s := range e.Bands {
+			if bands[b] == nil {
+				bands[b] = map[string]adifparser.WorkedStatus{}
+			}
+			bands[b][code] = s
+			if isChallenge[b] {
+				challenge[code+"/"+b] = s
+			}
+		}
+		for m, s := range e.Modes {
+			if modes[m] == nil {
+				modes[m] = map[string]adifparser.WorkedStatus{}
+			}
+			modes[m][code] = s
+		}
+	}
+	report.Total = countStatuses(total)
+	report.Deleted = countStatuses(deleted)
+	report.Challenge = countStatuses(challenge)
+	for b, m := range bands {
+		report.Bands[b] = countStatuses(m)
+	}
+	for g, m := range modes {
+		report.Modes[g] = countStatuses(m)
+	}
+	sort.Slice(report.Entities, func(i, j int) bool {
+		a, _ := strconv.Atoi(report.Entities[i].DXCC)
+		b, _ := strconv.Atoi(report.Entities[j].DXCC)
+		return a < b
+	})
+	return report
+}
+
+// List the confirmed slots without the credit granted
+// (CREDIT_GRANTED or APP_LoTW_CREDIT_GRANTED), in the entity code order
+func (t *dxccTracker) Uncredited() []UncreditedSlot {
+	list := []UncreditedSlot{}
+	for award, slots := range t.slots {
+		for key, slot := range slots {
+			if slot.confirmed == nil || slot.granted {
+				continue
+			}
+			code, sub, _ := strings.Cut(key, "/")
+			if t.entities[code].Deleted && award != adifparser.CreditDXCC {
+				continue
+			}
+			u := UncreditedSlot{Award: award, DXCC: code, Record: slot.confirmed}
+			switch award {
+			case adifparser.CreditDXCCBand:
+				u.Band = sub
+			case adifparser.CreditDXCCMode:
+				u.Mode = sub
+			}
+			list = append(list, u)
+		}
+	}
+	awardOrder := map[string]int{adifparser.CreditDXCC: 0,
+		adifparser.CreditDXCCBand: 1, adifparser.CreditDXCCMode: 2}
+	sort.Slice(list, func(i, j int) bool {
+		a, _ := strconv.Atoi(list[i].DXCC)
+		b, _ := strconv.Atoi(list[j].DXCC)
+		if a != b {
+			return a < b
+		}
+		if list[i].Award != list[j].Award {
+			return awardOrder[list[i].Award] < awardOrder[list[j].Award]
+		}
+		if list[i].Band != list[j].Band {
+			return adifparser.BandIndex(list[i].Band) < adifparser.BandIndex(list[j].Band)
+		}
+		return list[i].Mode < list[j].Mode
+	})
+	return list
+}
+
+// Write the standings as a text table
+// W is worked, C is confirmed, and * marks the deleted entities
+func (report *DXCCReport) WriteTable(w io.Writer) error {
+	seen := map[string]bool{}
+	bands := []string{}
+	for _, e := range report.Entities {
+		for b := range e.Bands {
+			if !seen[b] {
+				seen[b] = true
+				bands = append(bands, b)
+			}
+		}
+	}
+	sortBands(bands)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+	fmt.Fprint(tw, "DXCC\tEntity\tMixed")
+	for _, m := range modeGroups {
+		fmt.Fprintf(tw, "\t%s", m)
+	}
+	for _, b := range bands {
+		fmt.Fprintf(tw, "\t%s", b)
+	}
+	fmt.Fprintln(tw)
+	for _, e := range report.Entities {
+		code := e.DXCC
+		if e.Deleted {
+			code += "*"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s", code, e.Country, statusMark(e.Status))
+		for _, m := range modeGroups {
+			fmt.Fprintf(tw, "\t%s", statusMark(e.Modes[m]))
+		}
+		for _, b := range bands {
+			fmt.Fprintf(tw, "\t%s", statusMark(e.Bands[b]))
+		}
+		fmt.Fprintln(tw)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nMixed: %d worked, %d confirmed, %d granted\n",
+		report.Total.Worked, report.Total.Confirmed, report.Granted)
+	for _, m := range modeGroups {
+		c := report.Modes[m]
+		fmt.Fprintf(w, "%s: %d worked, %d confirmed\n", m, c.Worked, c.Confirmed)
+	}
+	fmt.Fprintf(w, "Challenge: %d worked, %d confirmed\n",
+		report.Challenge.Worked, report.Challenge.Confirmed)
+	_, err := fmt.Fprintf(w, "Deleted: %d worked, %d confirmed\n",
+		report.Deleted.Worked, report.Deleted.Confirmed)
+	return err
+}
@@ -0,0 +1,123 @@
+package awards
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jj1bdx/adifparser"
+)
+
+const dxccTestData = "<call:4>W1AW<band:3>20m<mode:2>CW<dxcc:3>291<country:24>United States of America" +
+	"<lotw_qsl_rcvd:1>Y<credit_granted:22>DXCC:LOTW,DXCC_BAND:LOTW<eor>" +
+	"<call:4>K1XX<band:3>40m<mode:3>SSB<dxcc:3>291<qsl_rcvd:1>Y<eor>" +
+	"<call:5>JA1XX<band:3>20m<mode:3>FT8<dxcc:3>339<country:5>Japan<eor>" +
+	"<call:5>VK9XX<band:3>30m<mode:2>CW<dxcc:2>34<lotw_qsl_rcvd:1>Y<eor>" +
+	"<call:4>TT1X<band:3>15m<mode:4>RTTY<dxcc:3>999<qsl_rcvd:1>Y" +
+	"<app_lotw_dxcc_entity_status:7>Deleted<eor>" +
+	"<call:5>OLD1X<band:3>20m<mode:2>CW<dxcc:2>23<eor>" +
+	"<call:4>MM1X<band:3>20m<mode:2>CW<dxcc:1>0<eor>" +
+	"<call:4>6M1X<band:2>2m<mode:2>CW<dxcc:3>291<eor>"
+
+func TestDXCCTracker(t *testing.T) {
+	tracker := NewDXCCTracker()
+	tracker.SetDeletedEntities("023")
+	if err := tracker.AddAll(adifparser.NewADIFReader(strings.NewReader(dxccTestData))); err != nil {
+		t.Fatal(err)
+	}
+	report := tracker.Report()
+
+	for _, c := range []struct {
+		name          string
+		got, expected Count
+	}{
+		{"total", report.Total, Count{3, 2}},
+		{"deleted", report.Deleted, Count{2, 1}},
+		{"challenge", report.Challenge, Count{4, 3}},
+		{"20m", report.Bands["20m"], Count{2, 1}},
+		{"2m", report.Bands["2m"], Count{1, 0}},
+		{"CW", report.Modes[ModeCW], Count{2, 2}},
+		{"PHONE", report.Modes[ModePhone], Count{1, 1}},
+		{"DIGITAL", report.Modes[ModeDigital], Count{1, 0}},
+	} {
+		if c.got != c.expected {
+			t.Fatalf("%s: expected %+v, got %+v", c.name, c.expected, c.got)
+		}
+	}
+	if report.Granted != 1 || report.Skipped != 1 {
+		t.Fatalf("Unexpected granted %d or skipped %d", report.Granted, report.Skipped)
+	}
+	codes := []string{}
+	for _, e := range report.Entities {
+		codes = append(codes, e.DXCC)
+	}
+	if strings.Join(codes, ",") != "23,34,291,339,999" {
+		t.Fatalf("Unexpected entities %v", codes)
+	}
+	uncredited := tracker.Uncredited()
+	expected := []string{
+		"34 DXCC", "34 DXCC_BAND 30m", "34 DXCC_MODE CW",
+		"291 DXCC_BAND 40m", "291 DXCC_MODE CW", "291 DXCC_MODE PHONE",
+		"999 DXCC",
+	}
+	got := []string{}
+	for _, u := range uncredited {
+		s := u.DXCC + " " + u.Award
+		if u.Band != "" {
+			s += " " + u.Band
+		}
+		if u.Mode != "" {
+			s += " " + u.Mode
+		}
+		got = append(got, s)
+	}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+
+	// The report is not shared with the tracker
+	r := adifparser.NewADIFRecord()
+	r.SetValue("dxcc", "339")
+	r.SetValue("band", "40m")
+	r.SetValue("mode", "CW")
+	r.SetValue("lotw_qsl_rcvd", "Y")
+	tracker.Add(r)
+	ja := report.Entities[3]
+	if ja.Status != adifparser.Worked || len(ja.Bands) != 1 || len(ja.Modes) != 1 ||
+		tracker.Report().Entities[3].Status != adifparser.Confirmed {
+		t.Fatalf("Report changed by Add: %+v", ja)
+	}
+
+	var buf strings.Builder
+	if err := report.WriteTable(&buf); err != nil {
+		t.Fatal(err)
+	}
+	table := buf.String()
+	for _, line := range []string{
+		"DXCC Entity                   Mixed CW PHONE DIGITAL 40m 30m 20m 15m 2m",
+		"291  United States of America C     C  C     -       C   -   C   -   W",
+		"999*                          C     -  -     C       -   -   -   C   -",
+		"Mixed: 3 worked, 2 confirmed, 1 granted",
+		"Deleted: 2 worked, 1 confirmed",
+	} {
+		if !strings.Contains(table, line+"\n") {
+			t.Fatalf("Expected %q in:\n%s", line, table)
+		}
+	}
+}
+
+func TestModeGroup(t *testing.T) {
+	for _, c := range []struct{ mode, lotw, expected string }{
+		{"CW", "", ModeCW}, {"SSB", "", ModePhone}, {"FM", "", ModePhone},
+		{"FT8", "", ModeDigital}, {"MFSK", "", ModeDigital}, {"", "", ""},
+		{"JT65", "DATA", ModeDigital}, {"XYZ", "PHONE", ModePhone},
+	} {
+		r := adifparser.NewADIFRecord()
+		r.SetValue("mode", c.mode)
+		if c.lotw != "" {
+			r.SetValue("app_lotw_modegroup", c.lotw)
+		}
+		if got := ModeGroup(r); got != c.expected {
+			t.Fatalf("%s/%s: expected %q, got %q", c.mode, c.lotw, c.expected, got)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package awards
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jj1bdx/adifparser"
+)
+
+// Award names
+const (
+	AwardWAS  = "WAS"
+	AwardWAZ  = "WAZ"
+	AwardWPX  = "WPX"
+	AwardVUCC = "VUCC"
+)
+
+// Standings of an award counting keys such as states, zones, prefixes or grids
+type AwardReport struct {
+	Award string `json:"award"`
+	// Mixed (nil for VUCC, which is awarded per band)
+	Total *Count `json:"total,omitempty"`
+	// By band and by mode group
+	Bands map[string]Count `json:"bands"`
+	Modes map[string]Count `json:"modes"`
+	// Status by key
+	Keys map[string]adifparser.WorkedStatus `json:"keys"`
+	// Status by band and key, and by mode group and key
+	BandKeys map[string]map[string]adifparser.WorkedStatus `json:"band_keys"`
+	ModeKeys map[string]map[string]adifparser.WorkedStatus `json:"mode_keys"`
+	// QSOs not counted
+	Skipped int `json:"skipped"`
+}
+
+// Tracker of an award counting keys
+type awardTracker struct {
+	// Statuses of the QSOs added (the counts are computed by Report)
+	report *AwardReport
+	// Keys of a QSO (none if not counted)
+	keys func(adifparser.ADIFRecord) []string
+	// Order of the keys in the table
+	less func(a, b string) bool
+	// Whether the award is per band without the Mixed total
+	perBand bool
+}
+
+func newAwardTracker(award string, keys func(adifparser.ADIFRecord) []string,
+	less func(a, b string) bool) *awardTracker {
+	t := &awardTracker{keys: keys, less: less}
+	t.report = &AwardReport{Award: award}
+	t.report.Keys = make(map[string]adifparser.WorkedStatus)
+	t.report.BandKeys = make(map[string]map[string]adifparser.WorkedStatus)
+	t.report.ModeKeys = make(map[string]map[string]adifparser.WorkedStatus)
+	return t
+}
+
+func raiseIn(m map[string]map[string]adifparser.WorkedStatus, sub, key string,
+	s adifparser.WorkedStatus) {
+	if m[sub] == nil {
+		m[sub] = make(map[string]adifparser.WorkedStatus)
+	}
+	raise(m[sub], key, s)
+}
+
+// Add a QSO
+func (t *awardTracker) Add(r adifparser.ADIFRecord) {
+	keys := t.keys(r)
+	if len(keys) == 0 {
+		t.report.Skipped++
+		return
+	}
+	status := recordStatus(r)
+	band := adifparser.RecordBand(r)
+	mode := ModeGroup(r)
+	for _, key := range keys {
+		raise(t.report.Keys, key, status)
+		if band != "" {
+			raiseIn(t.report.BandKeys, band, key, status)
+		}
+		if mode != "" {
+			raiseIn(t.report.ModeKeys, mode, key, status)
+		}
+	}
+}
+
+// Add all the QSOs of the reader
+func (t *awardTracker) AddAll(rdr adifparser.ADIFReader) error {
+	for record, err := range adifparser.AllRecords(rdr) {
+		if err != nil {
+			return err
+		}
+		t.Add(record)
+	}
+	return nil
+}
+
+// Copy of the statuses
+func copyStatuses(m map[string]adifparser.WorkedStatus) map[string]adifparser.WorkedStatus {
+	c := make(map[string]adifparser.WorkedStatus, len(m))
+	for k, s := range m {
+		c[k] = s
+	}
+	return c
+}
+
+func copyStatusMaps(m map[string]map[string]adifparser.WorkedStatus) map[string]map[string]adifparser.WorkedStatus {
+	c := make(map[string]map[string]adifparser.WorkedStatus, len(m))
+	for k, sub := range m {
+		c[k] = copyStatuses(sub)
+	}
+	return c
+}
+
+// Compute the standings
+// The report is a copy not updated by the subsequent Add calls
+func (t *awardTracker) Report() *AwardReport {
+	report := &AwardReport{Award: t.report.Award, Skipped: t.report.Skipped}
+	report.Keys = copyStatuses(t.report.Keys)
+	report.BandKeys = copyStatusMaps(t.report.BandKeys)
+	report.ModeKeys = copyStatusMaps(t.report.ModeKeys)
+	if !t.perBand {
+		total := countStatuses(report.Keys)
+		report.Total = &total
+	}
+	report.Bands = make(map[string]Count)
+	for b, m := range report.BandKeys {
+		report.Bands[b] = countStatuses(m)
+	}
+	report.Modes = make(map[string]Count)
+	for g, m := range report.ModeKeys {
+		report.Modes[g] = countStatuses(m)
+	}
+	return report
+}
+
+// Write the standings as a text table
+// W is worked and C is confirmed
+func (t *awardTracker) WriteTable(w io.Writer) error {
+	report := t.Report()
+	keys := make([]string, 0, len(report.Keys))
+	for k := range report.Keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return t.less(keys[i], keys[j]) })
+	bands := make([]string, 0, len(report.BandKeys))
+	for b := range report.BandKeys {
+		bands = append(bands, b)
+	}
+	sortBands(bands)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+	fmt.Fprint(tw, report.Award)
+	if report.Total != nil {
+		fmt.Fprint(tw, "\tMixed")
+	}
+	for _, m := range modeGroups {
+		fmt.Fprintf(tw, "\t%s", m)
+	}
+	for _, b := range bands {
+		fmt.Fprintf(tw, "\t%s", b)
+	}
+	fmt.Fprintln(tw)
+	for _, k := range keys {
+		fmt.Fprint(tw, k)
+		if report.Total != nil {
+			fmt.Fprintf(tw, "\t%s", statusMark(report.Keys[k]))
+		}
+		for _, m := range modeGroups {
+			fmt.Fprintf(tw, "\t%s", statusMark(report.ModeKeys[m][k]))
+		}
+		for _, b := range bands {
+			fmt.Fprintf(tw, "\t%s", statusMark(report.BandKeys[b][k]))
+		}
+		fmt.Fprintln(tw)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	if report.Total != nil {
+		fmt.Fprintf(w, "Mixed: %d worked, %d confirmed\n",
+			report.Total.Worked, report.Total.Confirmed)
+	}
+	for _, m := range modeGroups {
+		c := report.Modes[m]
+		fmt.Fprintf(w, "%s: %d worked, %d confirmed\n", m, c.Worked, c.Confirmed)
+	}
+	for _, b := range bands {
+		c := report.Bands[b]
+		if _, err := fmt.Fprintf(w, "%s: %d worked, %d confirmed\n",
+			b, c.Worked, c.Confirmed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Order of the numeric keys
+func numericLess(a, b string) bool {
+	x, _ := strconv.Atoi(a)
+	y, _ := strconv.Atoi(b)
+	return x < y
+}
+
+func stringLess(a, b string) bool {
+	return a < b
+}
+
+// The 50 states of WAS
+var wasStates = map[string]bool{}
+
+func init() {
+	for _, s := range strings.Fields(
+		"AL AK AZ AR CA CO CT DE FL GA HI ID IL IN IA KS KY LA ME MD " +
+			"MA MI MN MS MO MT NE NV NH NJ NM NY NC ND OH OK OR PA RI SC " +
+			"SD TN TX UT VT VA WA WV WI WY") {
+		wasStates[s] = true
+	}
+}
+
+// DXCC entities of the WAS states: USA, Alaska and Hawaii
+var wasEntities = map[string]bool{"291": true, "6": true, "110": true}
+
+// Create a new Worked All States tracker
+// STATE is counted for the QSOs with the USA, Alaska and Hawaii
+// (or without DXCC); DC is not counted
+func NewWASTracker() *awardTracker {
+	return newAwardTracker(AwardWAS, func(r adifparser.ADIFRecord) []string {
+		code := adifparser.DXCCCode(adifparser.RecordValue(r, "dxcc"))
+		if code != "" && !wasEntities[code] {
+			return nil
+		}
+		state := strings.ToUpper(adifparser.RecordValue(r, "state"))
+		if !wasStates[state] {
+			return nil
+		}
+		return []string{state}
+	}, stringLess)
+}
+
+// Create a new Worked All Zones tracker
+// CQZ from 1 to 40 is counted
+func NewWAZTracker() *awardTracker {
+	return newAwardTracker(AwardWAZ, func(r adifparser.ADIFRecord) []string {
+		zone, err := strconv.Atoi(adifparser.RecordValue(r, "cqz"))
+		if err != nil || zone < 1 || zone > 40 {
+			return nil
+		}
+		return []string{strconv.Itoa(zone)}
+	}, numericLess)
+}
+
+// Create a new WPX tracker
+// PFX is counted if given, otherwise the prefix derived from CALL by WPXPrefix
+func NewWPXTracker() *awardTracker {
+	return newAwardTracker(AwardWPX, func(r adifparser.ADIFRecord) []string {
+		pfx := strings.ToUpper(adifparser.RecordValue(r, "pfx"))
+		if pfx == "" {
+			pfx = WPXPrefix(adifparser.RecordValue(r, "call"))
+		}
+		if pfx == "" {
+			return nil
+		}
+		return []string{pfx}
+	}, stringLess)
+}
+
+// Whether the band is 50MHz or higher
+func isVUCCBand(band string) bool {
+	return adifparser.BandIndex(band) >= adifparser.BandIndex("6m")
+}
+
+// Create a new VUCC tracker
+// QSOs on 6m and higher bands are counted by the 4-character grid squares
+// of GRIDSQUARE or VUCC_GRIDS; all the grids of a grid line or grid corner QSO
+// in VUCC_GRIDS are counted. VUCC is awarded per band (see AwardReport.Bands),
+// so the report has no Mixed total
+func NewVUCCTracker() *awardTracker {
+	t := newAwardTracker(AwardVUCC, func(r adifparser.ADIFRecord) []string {
+		if !isVUCCBand(adifparser.RecordBand(r)) {
+			return nil
+		}
+		grids := []string{}
+		if v := adifparser.RecordValue(r, "vucc_grids"); v != "" {
+			for _, g := range strings.Split(v, ",") {
+				if g = vuccGrid(g); g != "" {
+					grids = append(grids, g)
+				}
+			}
+		} else if g := vuccGrid(adifparser.RecordValue(r, "gridsquare")); g != "" {
+			grids = append(grids, g)
+		}
+		return grids
+	}, stringLess)
+	t.perBand = true
+	return t
+}
+
+// 4-character grid square ("" if invalid)
+func vuccGrid(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) < 4 || s[0] < 'A' || s[0] > 'R' || s[1] < 'A' || s[1] > 'R' ||
+		s[2] < '0' || s[2] > '9' || s[3] < '0' || s[3] > '9' {
+		return ""
+	}
+	return s[:4]
+}
@@ -0,0 +1,148 @@
+package awards
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jj1bdx/adifparser"
+)
+
+func testTracker(t *testing.T, tracker *awardTracker, data string) *AwardReport {
+	t.Helper()
+	if err := tracker.AddAll(adifparser.NewADIFReader(strings.NewReader(data))); err != nil {
+		t.Fatal(err)
+	}
+	return tracker.Report()
+}
+
+func testKeys(t *testing.T, m map[string]adifparser.WorkedStatus,
+	expected map[string]adifparser.WorkedStatus) {
+	t.Helper()
+	if len(m) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, m)
+	}
+	for k, s := range expected {
+		if m[k] != s {
+			t.Fatalf("%s: expected %v, got %v", k, s, m[k])
+		}
+	}
+}
+
+func TestWASTracker(t *testing.T) {
+	report := testTracker(t, NewWASTracker(),
+		"<call:4>W1AW<state:2>CT<band:3>20m<mode:2>CW<dxcc:3>291<qsl_rcvd:1>Y<eor>"+
+			"<call:4>K1XX<state:2>ct<band:3>40m<mode:3>SSB<dxcc:3>291<eor>"+
+			"<call:5>KH6XX<state:2>HI<band:3>20m<mode:3>FT8<dxcc:3>110<eor>"+
+			"<call:4>K3XX<state:2>DC<band:3>20m<mode:2>CW<dxcc:3>291<eor>"+
+			"<call:5>VE3XX<state:2>ON<band:3>20m<mode:2>CW<dxcc:1>1<eor>"+
+			"<call:4>K4XX<state:2>GA<band:3>20m<mode:2>CW<eor>")
+	testKeys(t, report.Keys, map[string]adifparser.WorkedStatus{
+		"CT": adifparser.Confirmed, "HI": adifparser.Worked, "GA": adifparser.Worked})
+	testKeys(t, report.BandKeys["40m"], map[string]adifparser.WorkedStatus{
+		"CT": adifparser.Worked})
+	// The report is not shared with the tracker
+	tracker := NewWASTracker()
+	before := tracker.Report()
+	r := adifparser.NewADIFRecord()
+	r.SetValue("state", "ME")
+	r.SetValue("band", "20m")
+	tracker.Add(r)
+	if len(before.Keys) != 0 || len(before.BandKeys) != 0 || *before.Total != (Count{}) ||
+		len(tracker.Report().Keys) != 1 {
+		t.Fatalf("Report changed by Add: %+v", before)
+	}
+	if *report.Total != (Count{3, 1}) || report.Bands["20m"] != (Count{3, 1}) ||
+		report.Modes[ModePhone] != (Count{1, 0}) || report.Skipped != 2 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+}
+
+func TestWAZTracker(t *testing.T) {
+	report := testTracker(t, NewWAZTracker(),
+		"<call:4>W1AW<cqz:2>05<band:3>20m<mode:2>CW<lotw_qsl_rcvd:1>Y<eor>"+
+			"<call:5>JA1XX<cqz:2>25<band:3>15m<mode:2>CW<eor>"+
+			"<call:5>XX1XX<cqz:2>41<band:3>15m<mode:2>CW<eor>")
+	testKeys(t, report.Keys, map[string]adifparser.WorkedStatus{
+		"5": adifparser.Confirmed, "25": adifparser.Worked})
+	var buf strings.Builder
+	tracker := NewWAZTracker()
+	tracker.Add(adifparser.NewADIFRecord())
+	for _, key := range []string{"25", "5"} {
+		r := adifparser.NewADIFRecord()
+		r.SetValue("cqz", key)
+		r.SetValue("band", "20m")
+		r.SetValue("mode", "CW")
+		tracker.Add(r)
+	}
+	if err := tracker.WriteTable(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `WAZ Mixed CW PHONE DIGITAL 20m
+5   W     W  -     -       W
+25  W     W  -     -       W
+
+Mixed: 2 worked, 0 confirmed
+CW: 2 worked, 0 confirmed
+PHONE: 0 worked, 0 confirmed
+DIGITAL: 0 worked, 0 confirmed
+20m: 2 worked, 0 confirmed
+`
+	if buf.String() != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
+
+func TestWPXTracker(t *testing.T) {
+	report := testTracker(t, NewWPXTracker(),
+		"<call:5>N8BJQ<band:3>20m<mode:2>CW<eor>"+
+			"<call:9>N8BJQ/KH6<band:3>20m<mode:2>CW<qsl_rcvd:1>Y<eor>"+
+			"<call:5>N8XYZ<band:3>40m<mode:2>CW<qsl_rcvd:1>Y<eor>"+
+			"<call:6>OH2XXX<pfx:3>OG2<band:3>40m<mode:3>SSB<eor>")
+	testKeys(t, report.Keys, map[string]adifparser.WorkedStatus{
+		"N8": adifparser.Confirmed, "KH6": adifparser.Confirmed, "OG2": adifparser.Worked})
+	if report.Bands["20m"] != (Count{2, 1}) || report.Modes[ModeCW] != (Count{2, 2}) {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+}
+
+func TestVUCCTracker(t *testing.T) {
+	report := testTracker(t, NewVUCCTracker(),
+		"<call:4>W1AW<gridsquare:6>FN31pr<band:2>6m<mode:2>CW<qsl_rcvd:1>Y<eor>"+
+			"<call:5>K1ABC<vucc_grids:19>FN42,FN43,FN52,FN53<gridsquare:4>FN42"+
+			"<band:2>2m<mode:3>SSB<eor>"+
+			"<call:5>K2ABC<vucc_grids:9>FN31,FN32<freq:5>50.31<mode:3>FT8<eor>"+
+			"<call:5>K3ABC<gridsquare:4>FM19<band:3>20m<mode:2>CW<eor>"+
+			"<call:5>K4ABC<gridsquare:2>FM<band:2>6m<mode:2>CW<eor>")
+	testKeys(t, report.BandKeys["6m"], map[string]adifparser.WorkedStatus{
+		"FN31": adifparser.Confirmed, "FN32": adifparser.Worked})
+	testKeys(t, report.BandKeys["2m"], map[string]adifparser.WorkedStatus{
+		"FN42": adifparser.Worked, "FN43": adifparser.Worked,
+		"FN52": adifparser.Worked, "FN53": adifparser.Worked})
+	// No Mixed total across the bands
+	if report.Bands["2m"] != (Count{4, 0}) || report.Bands["6m"] != (Count{2, 1}) ||
+		report.Total != nil || report.Skipped != 2 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+
+	var buf strings.Builder
+	tracker := NewVUCCTracker()
+	r := adifparser.NewADIFRecord()
+	r.SetValue("gridsquare", "FN31")
+	r.SetValue("band", "6m")
+	r.SetValue("mode", "CW")
+	tracker.Add(r)
+	if err := tracker.WriteTable(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `VUCC CW PHONE DIGITAL 6m
+FN31 W  -     -       W
+
+CW: 1 worked, 0 confirmed
+PHONE: 0 worked, 0 confirmed
+DIGITAL: 0 worked, 0 confirmed
+6m: 1 worked, 0 confirmed
+`
+	if buf.String() != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
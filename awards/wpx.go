@@ -0,0 +1,94 @@
+package awards
+
+import (
+	"strings"
+)
+
+// Portable designators not changing the prefix
+// Other single-letter designators (e.g. /J) are ignored as well
+var wpxIgnoredDesignators = map[string]bool{
+	"P": true, "M": true, "MM": true, "AM": true, "QRP": true,
+	"A": true, "B": true, "R": true, "LH": true,
+}
+
+// Whether the part of a call is an ignored designator
+func isIgnoredDesignator(s string) bool {
+	return wpxIgnoredDesignators[s] || (len(s) == 1 && !isDigit(s[0]))
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// Prefix of a call or a portable designator without the slash:
+// the characters up to the end of the first digits following a letter
+// (e.g. N8 of N8BJQ, 3DA0 of 3DA0RU, S21 of S21AB)
+// A call without digits has 0 after the first two letters (e.g. RA0 of RAEM)
+func callPrefix(s string) string {
+	seenLetter := false
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			seenLetter = true
+			continue
+		}
+		if !seenLetter {
+			continue
+		}
+		j := i
+		for j < len(s) && isDigit(s[j]) {
+			j++
+		}
+		return s[:j]
+	}
+	if len(s) > 2 {
+		s = s[:2]
+	}
+	return s + "0"
+}
+
+// Derive the WPX prefix of a call
+//
+//   - N8BJQ is N8, WD8MGQ is WD8, and RAEM (no digits) is RA0
+//   - a portable designator is the prefix: N8BJQ/KH6 and KH6/N8BJQ are KH6,
+//     and PA/N8BJQ is PA0
+//   - a portable number replaces the digits: N8BJQ/9 is N9
+//   - /P, /M, /MM, /AM, /QRP, /A, /B, /R, /LH and the other single letters
+//     are ignored
+//
+// Of the two parts of a call with a designator, the shorter one
+// is the designator (the first one if the same length)
+func WPXPrefix(call string) string {
+	parts := []string{}
+	for _, p := range strings.Split(strings.ToUpper(strings.TrimSpace(call)), "/") {
+		if p != "" && !isIgnoredDesignator(p) {
+			parts = append(parts, p)
+		}
+	}
+	switch len(parts) {
+	case 0:
+		return ""
+	case 1:
+		return callPrefix(parts[0])
+	}
+	base, designator := parts[0], parts[1]
+	if len(designator) > len(base) {
+		base, designator = designator, base
+	} else if len(designator) == len(base) {
+		designator = parts[0]
+		base = parts[1]
+	}
+	if isAllDigits(designator) {
+		pfx := callPrefix(base)
+		return strings.TrimRight(pfx, "0123456789") + designator
+	}
+	return callPrefix(designator)
+}
+
+func isAllDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return s != ""
+}
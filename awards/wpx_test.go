@@ -0,0 +1,17 @@
+package awards
+
+import "testing"
+
+func TestWPXPrefix(t *testing.T) {
+	for call, expected := range map[string]string{
+		"N8BJQ": "N8", "WD8MGQ": "WD8", "3DA0RU": "3DA0", "S21AB": "S21",
+		"9A1A": "9A1", "2E0ABC": "2E0", "4X4DZ": "4X4", "RAEM": "RA0",
+		"n8bjq/kh6": "KH6", "KH6/N8BJQ": "KH6", "PA/N8BJQ": "PA0",
+		"N8BJQ/9": "N9", "WD8MGQ/0": "WD0", "N8BJQ/P": "N8", "JA1XX/QRP": "JA1",
+		"VE3ABC/MM": "VE3", "N8BJQ/R": "N8", "N8BJQ/J": "N8", "KH6/N8BJQ/R": "KH6", "KL7/JA1ZZZ/P": "KL7", "": "",
+	} {
+		if got := WPXPrefix(call); got != expected {
+			t.Fatalf("%s: expected %q, got %q", call, expected, got)
+		}
+	}
+}
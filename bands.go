@@ -0,0 +1,111 @@
+package adifparser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Frequency range of an ADIF band in MHz
+type bandRange struct {
+	name  string
+	lower float64
+	upper float64
+}
+
+// ADIF 3.1.4 Band Enumeration
+var adifBands = []bandRange{
+	{"2190m", 0.1357, 0.1378},
+	{"630m", 0.472, 0.479},
+	{"560m", 0.501, 0.504},
+	{"160m", 1.8, 2.0},
+	{"80m", 3.5, 4.0},
+	{"60m", 5.06, 5.45},
+	{"40m", 7.0, 7.3},
+	{"30m", 10.1, 10.15},
+	{"20m", 14.0, 14.35},
+	{"17m", 18.068, 18.168},
+	{"15m", 21.0, 21.45},
+	{"12m", 24.890, 24.99},
+	{"10m", 28.0, 29.7},
+	{"8m", 40, 45},
+	{"6m", 50, 54},
+	{"5m", 54.000001, 69.9},
+	{"4m", 70, 71},
+	{"2m", 144, 148},
+	{"1.25m", 222, 225},
+	{"70cm", 420, 450},
+	{"33cm", 902, 928},
+	{"23cm", 1240, 1300},
+	{"13cm", 2300, 2450},
+	{"9cm", 3300, 3500},
+	{"6cm", 5650, 5925},
+	{"3cm", 10000, 10500},
+	{"1.25cm", 24000, 24250},
+	{"6mm", 47000, 47200},
+	{"4mm", 75500, 81000},
+	{"2.5mm", 119980, 123000},
+	{"2mm", 134000, 149000},
+	{"1mm", 241000, 250000},
+	{"submm", 300000, 7500000},
+}
+
+// Band of the frequency in MHz (empty if out of the bands)
+func BandForFreq(freq float64) string {
+	for _, b := range adifBands {
+		if b.lower <= freq && freq <= b.upper {
+			return b.name
+		}
+	}
+	return ""
+}
+
+// Frequency range of the band
+func lookupBand(name string) (bandRange, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, b := range adifBands {
+		if b.name == name {
+			return b, true
+		}
+	}
+	return bandRange{}, false
+}
+
+// Position of the band in the ascending frequency order (-1 if unknown)
+func BandIndex(name string) int {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i, b := range adifBands {
+		if b.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Band of the record in lowercase, from BAND or FREQ
+func RecordBand(r ADIFRecord) string {
+	if band, err := r.GetValue("band"); err == nil && band != "" {
+		return strings.ToLower(band)
+	}
+	if freq, err := r.GetValue("freq"); err == nil {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(freq), 64); err == nil {
+			return BandForFreq(f)
+		}
+	}
+	return ""
+}
+
+// Value of a field of the record with the spaces trimmed (empty if not present)
+func RecordValue(r ADIFRecord, name string) string {
+	v, _ := r.GetValue(name)
+	return strings.TrimSpace(v)
+}
+
+// Normalized DXCC entity code without the leading zeros
+// (e.g. "001" to "1"); empty for none (0) and invalid codes
+func DXCCCode(s string) string {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n <= 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
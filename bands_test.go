@@ -0,0 +1,33 @@
+package adifparser
+
+import "testing"
+
+func TestBandForFreq(t *testing.T) {
+	for freq, band := range map[float64]string{
+		0.136: "2190m", 1.8: "160m", 14.074: "20m", 14.5: "", 50.313: "6m",
+		144.2: "2m", 432.1: "70cm", 10368.1: "3cm", 8.0: "",
+	} {
+		if got := BandForFreq(freq); got != band {
+			t.Fatalf("%v: expected %q, got %q", freq, band, got)
+		}
+	}
+}
+
+func TestDXCCCode(t *testing.T) {
+	for s, expected := range map[string]string{
+		"291": "291", "001": "1", " 339 ": "339", "0": "", " 0": "",
+		"": "", "-1": "", "JA": "",
+	} {
+		if got := DXCCCode(s); got != expected {
+			t.Fatalf("%q: expected %q, got %q", s, expected, got)
+		}
+	}
+	r := NewADIFRecord()
+	r.SetValue("dxcc", " 339 ")
+	if v := RecordValue(r, "dxcc"); v != "339" {
+		t.Fatalf("Unexpected value %q", v)
+	}
+	if v := RecordValue(r, "call"); v != "" {
+		t.Fatalf("Unexpected value %q", v)
+	}
+}
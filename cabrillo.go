@@ -0,0 +1,381 @@
+package adifparser
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Cabrillo 3.0 contest log export
+//
+// A Cabrillo log has START-OF-LOG, the header tags,
+// a QSO: line for each QSO, and END-OF-LOG.
+// A QSO: line has the frequency, mode, date, time,
+// and the sent and received exchanges in the columns
+// given by the exchange template of the contest.
+
+const CabrilloVersion string = "3.0"
+
+// Errors
+var ErrCabrilloTemplate = errors.New("invalid Cabrillo exchange template")
+var ErrCabrilloMissingField = errors.New("required QSO field missing")
+
+// Header tag such as CONTEST, CALLSIGN or CATEGORY-OPERATOR
+type CabrilloTag struct {
+	Name  string
+	Value string
+}
+
+// Header tags in the order of output
+// ADDRESS and SOAPBOX may appear more than once
+type CabrilloHeader []CabrilloTag
+
+// Get the first value of the tag
+func (h CabrilloHeader) Get(name string) string {
+	name = strings.ToUpper(name)
+	for _, t := range h {
+		if t.Name == name {
+			return t.Value
+		}
+	}
+	return ""
+}
+
+// Get all the values of the tag
+func (h CabrilloHeader) Values(name string) []string {
+	name = strings.ToUpper(name)
+	values := []string{}
+	for _, t := range h {
+		if t.Name == name {
+			values = append(values, t.Value)
+		}
+	}
+	return values
+}
+
+// Set the value of the tag, replacing the first one if exists
+func (h *CabrilloHeader) Set(name string, value string) {
+	name = strings.ToUpper(name)
+	for i, t := range *h {
+		if t.Name == name {
+			(*h)[i].Value = value
+			return
+		}
+	}
+	h.Add(name, value)
+}
+
+// Add a tag line
+func (h *CabrilloHeader) Add(name string, value string) {
+	*h = append(*h, CabrilloTag{strings.ToUpper(name), value})
+}
+
+// Column of an exchange in the QSO: line
+type CabrilloColumn struct {
+	// ADIF field names; the first non-empty value is written,
+	// and the value read is set to the first name
+	Names []string
+	// Number of whitespace-separated tokens of the value
+	// (e.g. 2 for a stx_string of "3A EMA")
+	Tokens int
+	// Minimum width of the column
+	Width int
+}
+
+// Exchange template of a contest
+type CabrilloTemplate struct {
+	Sent []CabrilloColumn
+	Rcvd []CabrilloColumn
+}
+
+// Parse a column list of a template
+// Each column is "name[|name...][*tokens][:width]",
+// e.g. "station_callsign:13 rst_sent:3 stx_string|stx:6"
+func parseCabrilloColumns(spec string) ([]CabrilloColumn, error) {
+	columns := []CabrilloColumn{}
+	for _, item := range strings.Fields(spec) {
+		column := CabrilloColumn{Tokens: 1}
+		names, width, found := strings.Cut(item, ":")
+		if found {
+			w, err := strconv.Atoi(width)
+			if err != nil || w < 0 {
+				return nil, fmt.Errorf("%w: width of %s", ErrCabrilloTemplate, item)
+			}
+			column.Width = w
+		}
+		names, tokens, found := strings.Cut(names, "*")
+		if found {
+			n, err := strconv.Atoi(tokens)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: tokens of %s", ErrCabrilloTemplate, item)
+			}
+			column.Tokens = n
+		}
+		for _, name := range strings.Split(names, "|") {
+			if name == "" {
+				return nil, fmt.Errorf("%w: field name of %s", ErrCabrilloTemplate, item)
+			}
+			column.Names = append(column.Names, strings.ToLower(name))
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// Parse an exchange template from the sent and received column lists
+func ParseCabrilloTemplate(sent, rcvd string) (CabrilloTemplate, error) {
+	var t CabrilloTemplate
+	var err error
+	if t.Sent, err = parseCabrilloColumns(sent); err != nil {
+		return t, err
+	}
+	if t.Rcvd, err = parseCabrilloColumns(rcvd); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+func mustParseCabrilloTemplate(sent, rcvd string) CabrilloTemplate {
+	t, err := ParseCabrilloTemplate(sent, rcvd)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Template of RST and a serial number or a free-form exchange
+var CabrilloDefaultTemplate = mustParseCabrilloTemplate(
+	"station_callsign:13 rst_sent:3 stx_string|stx:6",
+	"call:13 rst_rcvd:3 srx_string|srx:6")
+
+// Exchange templates by CONTEST name
+var CabrilloTemplates = map[string]CabrilloTemplate{}
+
+func addCabrilloTemplate(t CabrilloTemplate, contests ...string) {
+	for _, c := range contests {
+		CabrilloTemplates[c] = t
+	}
+}
+
+func init() {
+	addCabrilloTemplate(CabrilloDefaultTemplate,
+		"CQ-WPX-CW", "CQ-WPX-SSB", "CQ-WPX-RTTY")
+	addCabrilloTemplate(mustParseCabrilloTemplate(
+		"station_callsign:13 rst_sent:3 my_cq_zone|stx_string:6",
+		"call:13 rst_rcvd:3 cqz|srx_string:6"),
+		"CQ-WW-CW", "CQ-WW-SSB")
+	addCabrilloTemplate(mustParseCabrilloTemplate(
+		"station_callsign:13 rst_sent:3 stx_string*2:9",
+		"call:13 rst_rcvd:3 srx_string*2:9"),
+		"CQ-WW-RTTY")
+	addCabrilloTemplate(mustParseCabrilloTemplate(
+		"station_callsign:13 rst_sent:3 my_itu_zone|stx_string:6",
+		"call:13 rst_rcvd:3 ituz|srx_string:6"),
+		"IARU-HF")
+	addCabrilloTemplate(mustParseCabrilloTemplate(
+		"station_callsign:13 rst_sent:3 my_state|stx_string:6",
+		"call:13 rst_rcvd:3 state|srx_string:6"),
+		"ARRL-DX-CW", "ARRL-DX-SSB")
+	addCabrilloTemplate(mustParseCabrilloTemplate(
+		"station_callsign:13 stx_string*2:9",
+		"call:13 class:3 arrl_sect:3"),
+		"ARRL-FD", "WFD")
+	addCabrilloTemplate(mustParseCabrilloTemplate(
+		"station_callsign:10 stx:4 stx_string*3:9",
+		"call:10 srx:4 precedence:1 check:2 arrl_sect:3"),
+		"ARRL-SS-CW", "ARRL-SS-SSB")
+	addCabrilloTemplate(mustParseCabrilloTemplate(
+		"station_callsign:13 my_name:10 my_state|stx_string:3",
+		"call:13 name:10 state|ve_prov|srx_string:3"),
+		"NAQP-CW", "NAQP-SSB", "NAQP-RTTY")
+}
+
+// Exchange template of the contest
+// CabrilloDefaultTemplate is returned for an unknown contest
+func LookupCabrilloTemplate(contest string) CabrilloTemplate {
+	if t, ok := CabrilloTemplates[strings.ToUpper(strings.TrimSpace(contest))]; ok {
+		return t
+	}
+	return CabrilloDefaultTemplate
+}
+
+// Cabrillo band designators above 30MHz
+var cabrilloBandDesignators = map[string]string{
+	"6m": "50", "4m": "70", "2m": "144", "1.25m": "222", "70cm": "432",
+	"33cm": "902", "23cm": "1.2G", "13cm": "2.3G", "9cm": "3.4G",
+	"6cm": "5.7G", "3cm": "10G", "1.25cm": "24G", "6mm": "47G",
+	"4mm": "75G", "2.5mm": "123G", "2mm": "134G", "1mm": "241G",
+	"submm": "LIGHT",
+}
+
+// Frequency column: kHz below 30MHz, the band designator otherwise
+// Without FREQ, the lower edge of the band is used below 30MHz
+func cabrilloFreq(r ADIFRecord) (string, error) {
+	band := RecordBand(r)
+	if d, ok := cabrilloBandDesignators[band]; ok {
+		return d, nil
+	}
+	if freq, err := r.GetValue("freq"); err == nil {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(freq), 64); err == nil && f < 30 {
+			return strconv.FormatInt(int64(math.Round(f*1000)), 10), nil
+		}
+	}
+	if b, ok := lookupBand(band); ok && b.upper < 30 {
+		return strconv.FormatInt(int64(math.Round(b.lower*1000)), 10), nil
+	}
+	return "", fmt.Errorf("%w: freq or band", ErrCabrilloMissingField)
+}
+
+// Mode column: CW, PH, FM, RY or DG
+func cabrilloMode(mode string) string {
+	switch strings.ToUpper(mode) {
+	case "CW":
+		return "CW"
+	case "SSB", "AM", "PH", "USB", "LSB":
+		return "PH"
+	case "FM":
+		return "FM"
+	case "RTTY", "RY":
+		return "RY"
+	}
+	return "DG"
+}
+
+// Writer of a Cabrillo log
+type cabrilloWriter struct {
+	writer   *bufio.Writer
+	header   CabrilloHeader
+	template CabrilloTemplate
+	started  bool
+}
+
+// Create a new Cabrillo writer
+// The exchange template is chosen by the CONTEST tag of the header
+// Close must be called to write END-OF-LOG
+func NewCabrilloWriter(w io.Writer, header CabrilloHeader) *cabrilloWriter {
+	writer := &cabrilloWriter{}
+	writer.writer = bufio.NewWriter(w)
+	writer.header = append(CabrilloHeader{}, header...)
+	writer.template = LookupCabrilloTemplate(header.Get("CONTEST"))
+	return writer
+}
+
+// Use the exchange template instead of the one of the contest
+func (writer *cabrilloWriter) SetTemplate(t CabrilloTemplate) error {
+	if writer.started {
+		return ErrOutputStarted
+	}
+	writer.template = t
+	return nil
+}
+
+// Add the comment as a SOAPBOX line
+func (writer *cabrilloWriter) SetComment(comment string) error {
+	if writer.started {
+		return ErrOutputStarted
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		writer.header.Add("SOAPBOX", line)
+	}
+	return nil
+}
+
+// Write START-OF-LOG and the header tags
+func (writer *cabrilloWriter) writeHeader() error {
+	if writer.started {
+		return nil
+	}
+	writer.started = true
+	fmt.Fprintf(writer.writer, "START-OF-LOG: %s\n", CabrilloVersion)
+	for _, t := range writer.header {
+		if _, err := fmt.Fprintf(writer.writer, "%s: %s\n", t.Name, t.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Value of an exchange column
+// station_callsign defaults to the CALLSIGN tag
+func (writer *cabrilloWriter) columnValue(r ADIFRecord, c CabrilloColumn) string {
+	for _, name := range c.Names {
+		if v, err := r.GetValue(name); err == nil && strings.TrimSpace(v) != "" {
+			return strings.ToUpper(strings.TrimSpace(v))
+		}
+		if name == "station_callsign" && writer.header.Get("CALLSIGN") != "" {
+			return strings.ToUpper(writer.header.Get("CALLSIGN"))
+		}
+	}
+	return ""
+}
+
+// Format a QSO: line
+func (writer *cabrilloWriter) qsoLine(r ADIFRecord) (string, error) {
+	get := func(name string) string {
+		v, _ := r.GetValue(name)
+		return strings.TrimSpace(v)
+	}
+	for _, k := range []string{"call", "mode", "qso_date", "time_on"} {
+		if get(k) == "" {
+			return "", fmt.Errorf("%w: %s", ErrCabrilloMissingField, k)
+		}
+	}
+	date, t := get("qso_date"), get("time_on")
+	if len(date) != 8 || len(t) < 4 {
+		return "", fmt.Errorf("%w: invalid date/time %s %s", ErrCabrilloMissingField, date, t)
+	}
+	freq, err := cabrilloFreq(r)
+	if err != nil {
+		return "", err
+	}
+
+	var line strings.Builder
+	fmt.Fprintf(&line, "QSO: %5s %-2s %s-%s-%s %s", freq, cabrilloMode(get("mode")),
+		date[:4], date[4:6], date[6:], t[:4])
+	for _, c := range append(append([]CabrilloColumn{}, writer.template.Sent...),
+		writer.template.Rcvd...) {
+		// An empty value would shift the whitespace-separated columns
+		v := writer.columnValue(r, c)
+		if v == "" {
+			return "", fmt.Errorf("%w: %s", ErrCabrilloMissingField,
+				strings.Join(c.Names, "|"))
+		}
+		fmt.Fprintf(&line, " %-*s", c.Width, v)
+	}
+	return strings.TrimRight(line.String(), " "), nil
+}
+
+// Write a QSO: line
+func (writer *cabrilloWriter) WriteRecord(r ADIFRecord) error {
+	line, err := writer.qsoLine(r)
+	if err != nil {
+		return err
+	}
+	if err := writer.writeHeader(); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(writer.writer, "%s\n", line)
+	return err
+}
+
+func (writer *cabrilloWriter) Flush() error {
+	if err := writer.writeHeader(); err != nil {
+		return err
+	}
+	return writer.writer.Flush()
+}
+
+// Write END-OF-LOG and flush
+func (writer *cabrilloWriter) Close() error {
+	if err := writer.writeHeader(); err != nil {
+		return err
+	}
+	if _, err := writer.writer.WriteString("END-OF-LOG:\n"); err != nil {
+		return err
+	}
+	return writer.writer.Flush()
+}
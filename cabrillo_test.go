@@ -0,0 +1,161 @@
+package adifparser
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCabrilloWriter(t *testing.T) {
+	header := CabrilloHeader{}
+	header.Add("CONTEST", "ARRL-FD")
+	header.Add("CALLSIGN", "W1AW")
+	header.Add("CATEGORY-OPERATOR", "MULTI-OP")
+	header.Add("CLAIMED-SCORE", "1234")
+	header.Add("OPERATORS", "W1AW K1ABC")
+
+	testData := "<call:4>N1MM<freq:6>14.025<mode:2>CW<qso_date:8>20230624" +
+		"<time_on:6>180512<stx_string:6>3a ema<class:2>2A<arrl_sect:2>CT<eor>" +
+		"<call:4>K1XX<band:2>6m<mode:3>SSB<qso_date:8>20230624" +
+		"<time_on:4>1810<stx_string:6>3A EMA<class:2>1D<arrl_sect:3>WMA<eor>" +
+		"<call:4>K2XX<band:3>40m<mode:3>FT8<qso_date:8>20230625" +
+		"<time_on:4>0001<stx_string:6>3A EMA<class:2>1B<arrl_sect:3>NNY<eor>"
+
+	var buf bytes.Buffer
+	writer := NewCabrilloWriter(&buf, header)
+	if err := writer.SetComment("Fun!"); err != nil {
+		t.Fatal(err)
+	}
+	for record, err := range NewADIFReader(strings.NewReader(testData)).All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.WriteRecord(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `START-OF-LOG: 3.0
+CONTEST: ARRL-FD
+CALLSIGN: W1AW
+CATEGORY-OPERATOR: MULTI-OP
+CLAIMED-SCORE: 1234
+OPERATORS: W1AW K1ABC
+SOAPBOX: Fun!
+QSO: 14025 CW 2023-06-24 1805 W1AW          3A EMA    N1MM          2A  CT
+QSO:    50 PH 2023-06-24 1810 W1AW          3A EMA    K1XX          1D  WMA
+QSO:  7000 DG 2023-06-25 0001 W1AW          3A EMA    K2XX          1B  NNY
+END-OF-LOG:
+`
+	if buf.String() != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, buf.String())
+	}
+	if err := writer.SetComment("late"); err != ErrOutputStarted {
+		t.Fatalf("Expected %v, got %v", ErrOutputStarted, err)
+	}
+}
+
+func TestCabrilloWriterMissingField(t *testing.T) {
+	var buf bytes.Buffer
+	writer := NewCabrilloWriter(&buf, CabrilloHeader{})
+	record := NewADIFRecord()
+	record.SetValue("call", "N1MM")
+	record.SetValue("mode", "CW")
+	record.SetValue("qso_date", "20230624")
+	record.SetValue("time_on", "1805")
+	if err := writer.WriteRecord(record); !errors.Is(err, ErrCabrilloMissingField) {
+		t.Fatalf("Expected %v, got %v", ErrCabrilloMissingField, err)
+	}
+	record.SetValue("freq", "3.5255")
+	// Exchange values
+	for _, name := range []string{"rst_sent", "rst_rcvd", "srx"} {
+		record.SetValue(name, "599")
+	}
+	if err := writer.WriteRecord(record); !errors.Is(err, ErrCabrilloMissingField) ||
+		!strings.Contains(err.Error(), "station_callsign") {
+		t.Fatalf("Expected %v for station_callsign, got %v", ErrCabrilloMissingField, err)
+	}
+	record.SetValue("station_callsign", "W1AW")
+	if err := writer.WriteRecord(record); !errors.Is(err, ErrCabrilloMissingField) ||
+		!strings.Contains(err.Error(), "stx_string|stx") {
+		t.Fatalf("Expected %v for stx, got %v", ErrCabrilloMissingField, err)
+	}
+	record.SetValue("stx", "1")
+	if err := writer.WriteRecord(record); err != nil {
+		t.Fatal(err)
+	}
+	writer.Flush()
+	if !strings.Contains(buf.String(), "QSO:  3526 CW 2023-06-24 1805 W1AW          599 1      N1MM          599 599\n") {
+		t.Fatalf("Unexpected output %q", buf.String())
+	}
+}
+
+func TestCabrilloWriterNAQP(t *testing.T) {
+	header := CabrilloHeader{}
+	header.Add("CONTEST", "NAQP-CW")
+	header.Add("CALLSIGN", "N5XX")
+
+	var buf bytes.Buffer
+	writer := NewCabrilloWriter(&buf, header)
+	record := NewADIFRecord()
+	for k, v := range map[string]string{
+		"call": "K1XX", "band": "20m", "mode": "CW", "qso_date": "20240113",
+		"time_on": "1805", "my_name": "Bob", "my_state": "TX",
+		"name": "Ann", "state": "MA",
+	} {
+		record.SetValue(k, v)
+	}
+	if err := writer.WriteRecord(record); err != nil {
+		t.Fatal(err)
+	}
+	// The location is written with the name
+	record.SetValue("stx_string", "NM")
+	record.DeleteField("my_state")
+	if err := writer.WriteRecord(record); err != nil {
+		t.Fatal(err)
+	}
+	record.DeleteField("my_name")
+	if err := writer.WriteRecord(record); !errors.Is(err, ErrCabrilloMissingField) {
+		t.Fatalf("Expected %v, got %v", ErrCabrilloMissingField, err)
+	}
+	writer.Flush()
+
+	expected := `START-OF-LOG: 3.0
+CONTEST: NAQP-CW
+CALLSIGN: N5XX
+QSO: 14000 CW 2024-01-13 1805 N5XX          BOB        TX  K1XX          ANN        MA
+QSO: 14000 CW 2024-01-13 1805 N5XX          BOB        NM  K1XX          ANN        MA
+`
+	if buf.String() != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
+
+func TestCabrilloTemplate(t *testing.T) {
+	tmpl, err := ParseCabrilloTemplate("station_callsign:10 stx:4 stx_string*3:9",
+		"call:10 srx|srx_string:4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tmpl.Sent) != 3 || tmpl.Sent[2].Tokens != 3 || tmpl.Sent[2].Width != 9 {
+		t.Fatalf("Unexpected sent columns %+v", tmpl.Sent)
+	}
+	if len(tmpl.Rcvd[1].Names) != 2 || tmpl.Rcvd[1].Names[1] != "srx_string" {
+		t.Fatalf("Unexpected received columns %+v", tmpl.Rcvd)
+	}
+	for _, spec := range []string{"call:x", "call*0", "|call", "call:-1"} {
+		if _, err := ParseCabrilloTemplate(spec, ""); !errors.Is(err, ErrCabrilloTemplate) {
+			t.Fatalf("%q: expected %v, got %v", spec, ErrCabrilloTemplate, err)
+		}
+	}
+	if len(LookupCabrilloTemplate("arrl-ss-cw").Rcvd) != 5 {
+		t.Fatalf("Expected the ARRL-SS template")
+	}
+	if len(LookupCabrilloTemplate("NO-SUCH-CONTEST").Sent) != 3 {
+		t.Fatalf("Expected the default template")
+	}
+}
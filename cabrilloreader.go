@@ -0,0 +1,265 @@
+package adifparser
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors
+var ErrCabrilloLine = errors.New("uninterpretable Cabrillo line")
+
+// Line the reader could not interpret
+type CabrilloLineError struct {
+	// Line number from 1
+	Line int
+	Text string
+	Err  error
+}
+
+func (e *CabrilloLineError) Error() string {
+	return fmt.Sprintf("line %d: %v: %s", e.Line, e.Err, e.Text)
+}
+
+func (e *CabrilloLineError) Unwrap() error {
+	return e.Err
+}
+
+// Reader of a Cabrillo log
+// The QSO: lines are read as ADIF records;
+// X-QSO: lines and the lines after END-OF-LOG are skipped
+type cabrilloReader struct {
+	scanner *bufio.Scanner
+	// Current line number
+	line   int
+	header CabrilloHeader
+	// Exchange template (chosen by the CONTEST tag if not set)
+	template    CabrilloTemplate
+	hasTemplate bool
+	// First QSO: line read with the header
+	pending    string
+	hasPending bool
+	headerRead bool
+	ended      bool
+	// Lines not interpreted
+	unparsed []*CabrilloLineError
+	// Record count
+	records int
+}
+
+// Create a new Cabrillo reader
+func NewCabrilloReader(r io.Reader) *cabrilloReader {
+	reader := &cabrilloReader{}
+	reader.scanner = bufio.NewScanner(r)
+	return reader
+}
+
+// Use the exchange template instead of the one of the contest
+func (ardr *cabrilloReader) SetTemplate(t CabrilloTemplate) {
+	ardr.template = t
+	ardr.hasTemplate = true
+}
+
+// Read a line, returning io.EOF at the end
+func (ardr *cabrilloReader) readLine() (string, error) {
+	if ardr.hasPending {
+		ardr.hasPending = false
+		return ardr.pending, nil
+	}
+	if !ardr.scanner.Scan() {
+		if err := ardr.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	ardr.line++
+	return strings.TrimRight(ardr.scanner.Text(), " \t\r"), nil
+}
+
+// Record a line not interpreted
+func (ardr *cabrilloReader) reportLine(text string, err error) {
+	lerr := &CabrilloLineError{Line: ardr.line, Text: text, Err: err}
+	adiflog.Printf("Cabrillo: %v", lerr)
+	ardr.unparsed = append(ardr.unparsed, lerr)
+}
+
+// Read the header tags up to the first QSO: line
+func (ardr *cabrilloReader) readHeader() error {
+	for !ardr.headerRead {
+		line, err := ardr.readLine()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if line == "" {
+			continue
+		}
+		tag, value, found := strings.Cut(line, ":")
+		tag = strings.ToUpper(strings.TrimSpace(tag))
+		if !found || tag == "" {
+			ardr.reportLine(line, ErrCabrilloLine)
+			continue
+		}
+		switch tag {
+		case "START-OF-LOG":
+		case "QSO", "X-QSO", "END-OF-LOG":
+			ardr.pending = line
+			ardr.hasPending = true
+			ardr.headerRead = true
+		default:
+			ardr.header.Add(tag, strings.TrimSpace(value))
+		}
+	}
+	ardr.headerRead = true
+	if !ardr.hasTemplate {
+		ardr.template = LookupCabrilloTemplate(ardr.header.Get("CONTEST"))
+		ardr.hasTemplate = true
+	}
+	return nil
+}
+
+// Get the header tags
+func (ardr *cabrilloReader) Header() (CabrilloHeader, error) {
+	if err := ardr.readHeader(); err != nil {
+		return nil, err
+	}
+	return ardr.header, nil
+}
+
+// Get the lines not interpreted so far
+func (ardr *cabrilloReader) Unparsed() []*CabrilloLineError {
+	return ardr.unparsed
+}
+
+// Reverse map of cabrilloBandDesignators
+var cabrilloDesignatorBands = func() map[string]string {
+	m := make(map[string]string)
+	for band, d := range cabrilloBandDesignators {
+		m[d] = band
+	}
+	return m
+}()
+
+// ADIF modes of the Cabrillo modes
+// DG has no ADIF equivalent and leaves MODE unset
+var cabrilloADIFModes = map[string]string{
+	"CW": "CW", "PH": "SSB", "FM": "FM", "RY": "RTTY", "DG": "",
+}
+
+// Set freq and band from the frequency column
+func setCabrilloFreq(r ADIFRecord, freq string) error {
+	if band, ok := cabrilloDesignatorBands[strings.ToUpper(freq)]; ok {
+		r.SetValue("band", band)
+		return nil
+	}
+	khz, err := strconv.ParseFloat(freq, 64)
+	if err != nil || khz <= 0 {
+		return fmt.Errorf("%w: frequency %s", ErrCabrilloLine, freq)
+	}
+	mhz := khz / 1000
+	r.SetValue("freq", strconv.FormatFloat(mhz, 'f', -1, 64))
+	if band := BandForFreq(mhz); band != "" {
+		r.SetValue("band", band)
+	}
+	return nil
+}
+
+// Parse a QSO: line
+func (ardr *cabrilloReader) parseQSO(line string) (ADIFRecord, error) {
+	_, rest, _ := strings.Cut(line, ":")
+	tokens := strings.Fields(rest)
+	columns := append(append([]CabrilloColumn{}, ardr.template.Sent...),
+		ardr.template.Rcvd...)
+	required := 4
+	for _, c := range columns {
+		required += c.Tokens
+	}
+	// One more token is the transmitter ID, which is not kept
+	if len(tokens) != required && len(tokens) != required+1 {
+		return nil, fmt.Errorf("%w: %d columns, expected %d",
+			ErrCabrilloLine, len(tokens), required)
+	}
+
+	record := NewADIFRecord()
+	if err := setCabrilloFreq(record, tokens[0]); err != nil {
+		return nil, err
+	}
+	mode, ok := cabrilloADIFModes[strings.ToUpper(tokens[1])]
+	if !ok {
+		return nil, fmt.Errorf("%w: mode %s", ErrCabrilloLine, tokens[1])
+	}
+	if mode != "" {
+		record.SetValue("mode", mode)
+	}
+	date, err := time.Parse("2006-01-02", tokens[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: date %s", ErrCabrilloLine, tokens[2])
+	}
+	record.SetValue("qso_date", date.Format("20060102"))
+	if _, err := time.Parse("1504", tokens[3]); err != nil {
+		return nil, fmt.Errorf("%w: time %s", ErrCabrilloLine, tokens[3])
+	}
+	record.SetValue("time_on", tokens[3])
+
+	pos := 4
+	for _, c := range columns {
+		value := strings.Join(tokens[pos:pos+c.Tokens], " ")
+		pos += c.Tokens
+		record.SetValue(c.Names[0], value)
+	}
+	if contest := ardr.header.Get("CONTEST"); contest != "" {
+		record.SetValue("contest_id", contest)
+	}
+	return record, nil
+}
+
+// Read the next QSO: line as a record
+// Lines not interpreted are skipped and reported by Unparsed
+func (ardr *cabrilloReader) ReadRecord() (ADIFRecord, error) {
+	if err := ardr.readHeader(); err != nil {
+		return nil, err
+	}
+	for !ardr.ended {
+		line, err := ardr.readLine()
+		if err != nil {
+			return nil, err
+		}
+		tag, _, _ := strings.Cut(line, ":")
+		switch strings.ToUpper(strings.TrimSpace(tag)) {
+		case "":
+			if strings.TrimSpace(line) != "" {
+				ardr.reportLine(line, ErrCabrilloLine)
+			}
+		case "QSO":
+			record, err := ardr.parseQSO(line)
+			if err != nil {
+				ardr.reportLine(line, err)
+				continue
+			}
+			ardr.records++
+			return record, nil
+		case "X-QSO":
+		case "END-OF-LOG":
+			ardr.ended = true
+		default:
+			ardr.reportLine(line, ErrCabrilloLine)
+		}
+	}
+	return nil, io.EOF
+}
+
+func (ardr *cabrilloReader) RecordCount() int {
+	return ardr.records
+}
+
+// Iterate over the records
+func (ardr *cabrilloReader) All() iter.Seq2[ADIFRecord, error] {
+	return AllRecords(ardr)
+}
@@ -0,0 +1,118 @@
+package adifparser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCabrilloReader(t *testing.T) {
+	log := `START-OF-LOG: 3.0
+CONTEST: ARRL-SS-CW
+CALLSIGN: K4XU
+CATEGORY-OPERATOR: SINGLE-OP
+SOAPBOX: first line
+SOAPBOX: second line
+this is not a tag
+QSO: 21042 CW 2003-11-01 2101 K4XU        1 A 92 OR  K9PW        1 B 73 IL
+QSO: 21042 CW 2003-11-01 2102 K4XU        2 A 92 OR  N6TR        5 A 66
+X-QSO: 21042 CW 2003-11-01 2103 K4XU      3 A 92 OR  K1XX        9 A 70 CT
+QSO:   144 PH 2003-11-01 2104 K4XU        3 A 92 OR  W1AW       17 M 38 CT
+QSO: 21042 ZZ 2003-11-01 2105 K4XU        4 A 92 OR  W1AW       18 M 38 CT
+END-OF-LOG:
+QSO: 21042 CW 2003-11-01 2106 K4XU        5 A 92 OR  K5XX       20 U 99 NTX
+`
+	reader := NewCabrilloReader(strings.NewReader(log))
+	header, err := reader.Header()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("callsign") != "K4XU" || len(header.Values("SOAPBOX")) != 2 {
+		t.Fatalf("Unexpected header %v", header)
+	}
+
+	expected := []map[string]string{
+		{"freq": "21.042", "band": "15m", "mode": "CW", "qso_date": "20031101",
+			"time_on": "2101", "station_callsign": "K4XU", "stx": "1",
+			"stx_string": "A 92 OR", "call": "K9PW", "srx": "1",
+			"precedence": "B", "check": "73", "arrl_sect": "IL",
+			"contest_id": "ARRL-SS-CW"},
+		{"band": "2m", "mode": "SSB", "qso_date": "20031101", "time_on": "2104",
+			"station_callsign": "K4XU", "stx": "3", "stx_string": "A 92 OR",
+			"call": "W1AW", "srx": "17", "precedence": "M", "check": "38",
+			"arrl_sect": "CT", "contest_id": "ARRL-SS-CW"},
+	}
+	n := 0
+	for record, err := range reader.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n >= len(expected) {
+			t.Fatalf("Unexpected record %v", record)
+		}
+		exp := expected[n]
+		if len(record.GetFields()) != len(exp) {
+			t.Fatalf("Expected %d fields, got %v", len(exp), record.GetFields())
+		}
+		for k, v := range exp {
+			if got, err := record.GetValue(k); err != nil || got != v {
+				t.Fatalf("Field %s: expected %q, got %q (%v)", k, v, got, err)
+			}
+		}
+		n++
+	}
+	if n != len(expected) || reader.RecordCount() != len(expected) {
+		t.Fatalf("Expected %d records, got %d", len(expected), n)
+	}
+
+	unparsed := reader.Unparsed()
+	lines := []int{7, 9, 12}
+	if len(unparsed) != len(lines) {
+		t.Fatalf("Expected %d unparsed lines, got %v", len(lines), unparsed)
+	}
+	for i, l := range lines {
+		if unparsed[i].Line != l || !errors.Is(unparsed[i], ErrCabrilloLine) {
+			t.Fatalf("Unexpected unparsed line %v", unparsed[i])
+		}
+	}
+}
+
+func TestCabrilloRoundTrip(t *testing.T) {
+	header := CabrilloHeader{}
+	header.Add("CONTEST", "CQ-WW-CW")
+	header.Add("CALLSIGN", "JJ1BDX")
+
+	record := NewADIFRecord()
+	for k, v := range map[string]string{
+		"call": "W1AW", "freq": "7.0255", "mode": "CW", "qso_date": "20231125",
+		"time_on": "0102", "rst_sent": "599", "rst_rcvd": "599",
+		"my_cq_zone": "25", "cqz": "5"} {
+		record.SetValue(k, v)
+	}
+	var buf strings.Builder
+	writer := NewCabrilloWriter(&buf, header)
+	if err := writer.WriteRecord(record); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader := NewCabrilloReader(strings.NewReader(buf.String()))
+	got, err := reader.ReadRecord()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for k, v := range map[string]string{
+		"call": "W1AW", "freq": "7.026", "band": "40m", "mode": "CW",
+		"qso_date": "20231125", "time_on": "0102", "station_callsign": "JJ1BDX",
+		"rst_sent": "599", "rst_rcvd": "599", "my_cq_zone": "25", "cqz": "5",
+		"contest_id": "CQ-WW-CW"} {
+		if g, err := got.GetValue(k); err != nil || g != v {
+			t.Fatalf("Field %s: expected %q, got %q (%v)", k, v, g, err)
+		}
+	}
+	if len(reader.Unparsed()) != 0 {
+		t.Fatalf("Unexpected unparsed lines %v", reader.Unparsed())
+	}
+}
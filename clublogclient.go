@@ -0,0 +1,181 @@
+package adifparser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const ClubLogAPI string = "https://clublog.org"
+
+// Club Log client
+// QSOs are uploaded one by one with realtime.php,
+// or as a whole log with putlogs.php,
+// and the log is downloaded with getadif.php
+type clubLogClientImpl struct {
+	serviceClient
+	// Creds
+	email    string
+	password string
+	callsign string
+	apiKey   string
+	// Current time for the upload date
+	now func() time.Time
+}
+
+// Create a new Club Log client
+func NewClubLogClient(email, password, callsign, apiKey string,
+	opts ...ServiceClientOption) *clubLogClientImpl {
+	client := &clubLogClientImpl{}
+	client.init(ClubLogAPI, opts)
+	client.email = email
+	client.password = password
+	client.callsign = callsign
+	client.apiKey = apiKey
+	client.now = time.Now
+	return client
+}
+
+func (c *clubLogClientImpl) form() url.Values {
+	return url.Values{
+		"email":    {c.email},
+		"password": {c.password},
+		"callsign": {c.callsign},
+		"api":      {c.apiKey},
+	}
+}
+
+// Check the status code common to the Club Log APIs
+func clubLogStatusError(status int, body []byte) error {
+	switch {
+	case status == http.StatusForbidden:
+		return fmt.Errorf("%w: %s", ErrServiceAuth, strings.TrimSpace(string(body)))
+	case status >= 500 || (status != http.StatusOK && status != http.StatusBadRequest):
+		return fmt.Errorf("%w: %d %s", ErrServiceHTTPStatus, status,
+			strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// Upload a QSO with realtime.php
+// clublog_qso_upload_status and clublog_qso_upload_date are set
+// for the inserted and duplicate QSOs
+func (c *clubLogClientImpl) Upload(ctx context.Context, r ADIFRecord) (UploadResult, error) {
+	form := c.form()
+	form.Set("adif", recordADIF(r))
+	status, body, err := c.postForm(ctx, "/realtime.php", form)
+	if err != nil {
+		return UploadResult{}, err
+	}
+	if err := clubLogStatusError(status, body); err != nil {
+		return UploadResult{}, err
+	}
+	result := UploadResult{Record: r}
+	message := strings.TrimSpace(string(body))
+	switch {
+	case status == http.StatusBadRequest:
+		result.Status = UploadRejected
+		result.Reason = message
+	case strings.Contains(strings.ToLower(message), "dupe"):
+		result.Status = UploadDuplicate
+		result.Reason = message
+	default:
+		result.Status = UploadInserted
+	}
+	setUploadStatus(r, "clublog", result.Status, c.now())
+	return result, nil
+}
+
+// Upload all the QSOs of the reader with realtime.php
+// Stops at the first error other than a rejection
+func (c *clubLogClientImpl) UploadAll(ctx context.Context, rdr ADIFReader) ([]UploadResult, error) {
+	results := make([]UploadResult, 0, 16)
+	for record, err := range AllRecords(rdr) {
+		if err != nil {
+			return results, err
+		}
+		result, err := c.Upload(ctx, record)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Upload a whole log with putlogs.php
+// If clear is true, the existing log of the callsign is replaced
+// clublog_qso_upload_status and clublog_qso_upload_date are set
+// for all the records when accepted
+func (c *clubLogClientImpl) PutLogs(ctx context.Context, records []ADIFRecord, clear bool) error {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	form := c.form()
+	if clear {
+		form.Set("clear", "1")
+	} else {
+		form.Set("clear", "0")
+	}
+	for k, v := range form {
+		if err := mw.WriteField(k, v[0]); err != nil {
+			return err
+		}
+	}
+	fw, err := mw.CreateFormFile("file", "log.adi")
+	if err != nil {
+		return err
+	}
+	writer := NewADIFWriter(fw)
+	writer.SetComment("Uploaded by adifparser")
+	for _, r := range records {
+		if err := writer.WriteRecord(r); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+
+	status, body, err := c.post(ctx, "/putlogs.php", mw.FormDataContentType(), &buf)
+	if err != nil {
+		return err
+	}
+	if err := clubLogStatusError(status, body); err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("%w: %d %s", ErrServiceHTTPStatus, status,
+			strings.TrimSpace(string(body)))
+	}
+	now := c.now()
+	for _, r := range records {
+		setUploadStatus(r, "clublog", UploadInserted, now)
+	}
+	return nil
+}
+
+// Download the log of the callsign with getadif.php
+func (c *clubLogClientImpl) Download(ctx context.Context) ([]ADIFRecord, error) {
+	form := c.form()
+	form.Set("call", c.callsign)
+	status, body, err := c.postForm(ctx, "/getadif.php", form)
+	if err != nil {
+		return nil, err
+	}
+	if err := clubLogStatusError(status, body); err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: %d %s", ErrServiceHTTPStatus, status,
+			strings.TrimSpace(string(body)))
+	}
+	return parseADIFRecords(string(body))
+}
@@ -0,0 +1,137 @@
+package adifparser
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newClubLogTestServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	check := func(r *http.Request) bool {
+		if r.FormValue("email") != "e@example.com" || r.FormValue("callsign") != "JJ1BDX" ||
+			r.FormValue("api") != "k" {
+			t.Errorf("Unexpected form %v", r.Form)
+		}
+		return r.FormValue("password") == "p"
+	}
+	mux.HandleFunc("/realtime.php", func(w http.ResponseWriter, r *http.Request) {
+		if !check(r) {
+			http.Error(w, "Invalid login", http.StatusForbidden)
+			return
+		}
+		record, err := NewADIFReader(strings.NewReader(r.FormValue("adif"))).ReadRecord()
+		if err != nil {
+			t.Error(err)
+		}
+		switch call, _ := record.GetValue("call"); call {
+		case "W1AW":
+			w.Write([]byte("Dupe"))
+		case "XX0XX":
+			http.Error(w, "Rejected: invalid callsign", http.StatusBadRequest)
+		default:
+			w.Write([]byte("QSO OK"))
+		}
+	})
+	mux.HandleFunc("/putlogs.php", func(w http.ResponseWriter, r *http.Request) {
+		if !check(r) {
+			http.Error(w, "Invalid login", http.StatusForbidden)
+			return
+		}
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		data, _ := io.ReadAll(f)
+		if r.FormValue("clear") != "1" || strings.Count(string(data), "<eor>") != 3 {
+			t.Errorf("Unexpected upload %s", data)
+		}
+		w.Write([]byte("Upload accepted"))
+	})
+	mux.HandleFunc("/getadif.php", func(w http.ResponseWriter, r *http.Request) {
+		check(r)
+		w.Write([]byte("Club Log<eoh><call:4>W1AW<band:3>20m<eor><call:5>KL3MM<band:3>20m<eor>"))
+	})
+	return httptest.NewServer(mux)
+}
+
+const testServiceRecords = "<call:5>KL3MM<band:3>20m<mode:4>JT65<qso_date:8>20150523" +
+	"<time_on:4>0247<eor><call:4>W1AW<band:3>40m<mode:2>CW<qso_date:8>20150524" +
+	"<time_on:4>1200<eor><call:5>XX0XX<band:3>15m<mode:2>CW<qso_date:8>20150525" +
+	"<time_on:4>1300<eor>"
+
+func TestClubLogUpload(t *testing.T) {
+	server := newClubLogTestServer(t)
+	defer server.Close()
+
+	c := NewClubLogClient("e@example.com", "p", "JJ1BDX", "k", WithServiceBaseURL(server.URL))
+	c.now = func() time.Time { return time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC) }
+	results, err := c.UploadAll(context.Background(),
+		NewADIFReader(strings.NewReader(testServiceRecords)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []UploadStatus{UploadInserted, UploadDuplicate, UploadRejected}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d", len(expected), len(results))
+	}
+	for i, result := range results {
+		if result.Status != expected[i] {
+			t.Fatalf("Result %d: expected %v, got %v", i, expected[i], result.Status)
+		}
+		v, err := result.Record.GetValue("clublog_qso_upload_status")
+		if result.Status == UploadRejected {
+			if err == nil {
+				t.Fatal("Rejected QSO should not be marked")
+			}
+			if result.Reason != "Rejected: invalid callsign" {
+				t.Fatalf("Unexpected reason %q", result.Reason)
+			}
+			continue
+		}
+		if v != "Y" {
+			t.Fatalf("Expected upload status Y, got %q", v)
+		}
+		if d, _ := result.Record.GetValue("clublog_qso_upload_date"); d != "20261018" {
+			t.Fatalf("Unexpected upload date %q", d)
+		}
+	}
+
+	c = NewClubLogClient("e@example.com", "bad", "JJ1BDX", "k", WithServiceBaseURL(server.URL))
+	if _, err := c.Upload(context.Background(), results[0].Record); !errors.Is(err, ErrServiceAuth) {
+		t.Fatalf("Expected %v, got %v", ErrServiceAuth, err)
+	}
+}
+
+func TestClubLogPutLogsAndDownload(t *testing.T) {
+	server := newClubLogTestServer(t)
+	defer server.Close()
+
+	c := NewClubLogClient("e@example.com", "p", "JJ1BDX", "k", WithServiceBaseURL(server.URL))
+	records, err := parseADIFRecords(testServiceRecords)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.PutLogs(context.Background(), records, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range records {
+		if v, _ := r.GetValue("clublog_qso_upload_status"); v != "Y" {
+			t.Fatalf("Expected upload status Y, got %q", v)
+		}
+	}
+
+	downloaded, err := c.Download(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(downloaded) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(downloaded))
+	}
+}
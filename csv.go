@@ -0,0 +1,313 @@
+package adifparser
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Errors
+var ErrCSVValue = errors.New("invalid CSV value")
+var ErrCSVColumn = errors.New("field not in the CSV columns")
+
+// ADIF date and time layouts
+const (
+	adifDateLayout  = "20060102"
+	adifTimeLayout  = "150405"
+	adifShortLayout = "1504"
+)
+
+// CSV format options
+type CSVOptions struct {
+	// Field delimiter (',' if zero)
+	Comma rune
+	// Comment character of the reader (no comments if zero)
+	Comment rune
+	// Layout of the date fields in the time package format
+	// (e.g. "2006-01-02"; ADIF "20060102" if empty)
+	DateFormat string
+	// Layout of the time fields in the time package format
+	// (e.g. "15:04"; ADIF "150405" if empty)
+	TimeFormat string
+}
+
+func (o CSVOptions) comma() rune {
+	if o.Comma == 0 {
+		return ','
+	}
+	return o.Comma
+}
+
+// Convert an ADIF date or time value to the CSV format
+// Values not in the ADIF format are kept as is
+func (o CSVOptions) formatValue(name, value string) string {
+	switch ADIFfieldInfo[name].datatype {
+	case ADIFDate:
+		if o.DateFormat != "" {
+			if t, err := time.Parse(adifDateLayout, value); err == nil {
+				return t.Format(o.DateFormat)
+			}
+		}
+	case ADIFTime:
+		if o.TimeFormat != "" {
+			layout := adifTimeLayout
+			if len(value) == 4 {
+				layout = adifShortLayout
+			}
+			if t, err := time.Parse(layout, value); err == nil {
+				return t.Format(o.TimeFormat)
+			}
+		}
+	}
+	return value
+}
+
+// Convert a CSV date or time value to the ADIF format
+func (o CSVOptions) parseValue(name, value string) (string, error) {
+	switch ADIFfieldInfo[name].datatype {
+	case ADIFDate:
+		if o.DateFormat != "" {
+			t, err := time.Parse(o.DateFormat, value)
+			if err != nil {
+				return "", fmt.Errorf("%w: %s %q", ErrCSVValue, name, value)
+			}
+			return t.Format(adifDateLayout), nil
+		}
+	case ADIFTime:
+		if o.TimeFormat != "" {
+			t, err := time.Parse(o.TimeFormat, value)
+			if err != nil {
+				return "", fmt.Errorf("%w: %s %q", ErrCSVValue, name, value)
+			}
+			if strings.Contains(o.TimeFormat, "05") {
+				return t.Format(adifTimeLayout), nil
+			}
+			return t.Format(adifShortLayout), nil
+		}
+	}
+	return value, nil
+}
+
+// Field names of the records in the order of ADIFfieldOrder,
+// followed by the non-standard fields in alphabetical order
+func CSVColumns(records []ADIFRecord) []string {
+	seen := make(map[string]bool)
+	for _, r := range records {
+		for _, name := range r.GetFields() {
+			seen[name] = true
+		}
+	}
+	columns := make([]string, 0, len(seen))
+	for _, name := range ADIFfieldOrder {
+		if seen[name] {
+			columns = append(columns, name)
+			delete(seen, name)
+		}
+	}
+	others := make([]string, 0, len(seen))
+	for name := range seen {
+		others = append(others, name)
+	}
+	sort.Strings(others)
+	return append(columns, others...)
+}
+
+// Writer of CSV with a header row of the field names
+type csvWriter struct {
+	writer  *csv.Writer
+	options CSVOptions
+	columns []string
+	started bool
+	// Whether the columns are chosen by CSVColumns
+	chosen bool
+	// Records buffered until Flush to choose the columns
+	pending []ADIFRecord
+}
+
+// Create a new CSV writer
+// If columns is empty, the records are buffered until the first Flush
+// with a record,
+// and the columns are chosen by CSVColumns; a record written after that
+// with a field not in the columns is an ErrCSVColumn error
+// Give the columns to write a large log without buffering
+func NewCSVWriter(w io.Writer, columns []string, options CSVOptions) *csvWriter {
+	writer := &csvWriter{}
+	writer.writer = csv.NewWriter(w)
+	writer.writer.Comma = options.comma()
+	writer.options = options
+	for _, c := range columns {
+		writer.columns = append(writer.columns, strings.ToLower(c))
+	}
+	return writer
+}
+
+// Write the header row
+func (writer *csvWriter) writeHeader() error {
+	if writer.started {
+		return nil
+	}
+	writer.started = true
+	return writer.writer.Write(writer.columns)
+}
+
+func (writer *csvWriter) writeRow(r ADIFRecord) error {
+	row := make([]string, len(writer.columns))
+	for i, name := range writer.columns {
+		if v, err := r.GetValue(name); err == nil {
+			row[i] = writer.options.formatValue(name, v)
+		}
+	}
+	return writer.writer.Write(row)
+}
+
+func (writer *csvWriter) WriteRecord(r ADIFRecord) error {
+	if !writer.started && len(writer.columns) == 0 {
+		writer.pending = append(writer.pending, r)
+		return nil
+	}
+	if writer.chosen {
+		for _, name := range r.GetFields() {
+			if !slices.Contains(writer.columns, name) {
+				return fmt.Errorf("%w: %s", ErrCSVColumn, name)
+			}
+		}
+	}
+	if err := writer.writeHeader(); err != nil {
+		return err
+	}
+	return writer.writeRow(r)
+}
+
+// Flush the buffered records; nothing is written without a record
+func (writer *csvWriter) Flush() error {
+	if len(writer.pending) > 0 {
+		if !writer.started && len(writer.columns) == 0 {
+			writer.columns = CSVColumns(writer.pending)
+			writer.chosen = true
+		}
+		if err := writer.writeHeader(); err != nil {
+			return err
+		}
+		for _, r := range writer.pending {
+			if err := writer.writeRow(r); err != nil {
+				return err
+			}
+		}
+		writer.pending = nil
+	}
+	writer.writer.Flush()
+	return writer.writer.Error()
+}
+
+// CSV has no comment; the comment is ignored
+func (writer *csvWriter) SetComment(comment string) error {
+	if writer.started {
+		return ErrOutputStarted
+	}
+	return nil
+}
+
+// Reader of CSV with a header row
+type csvReader struct {
+	reader  *csv.Reader
+	options CSVOptions
+	mapping map[string]string
+	// Field names of the columns (empty to skip the column)
+	fields  []string
+	records int
+}
+
+// Create a new CSV reader
+// mapping maps the header names (case-insensitive) to the field names;
+// an empty field name skips the column
+// Columns not in mapping use the header name in lowercase
+// with spaces replaced by underscores (e.g. "QSO Date" to "qso_date")
+func NewCSVReader(r io.Reader, mapping map[string]string, options CSVOptions) *csvReader {
+	reader := &csvReader{}
+	reader.reader = csv.NewReader(r)
+	reader.reader.Comma = options.comma()
+	reader.reader.Comment = options.Comment
+	reader.reader.FieldsPerRecord = -1
+	reader.reader.TrimLeadingSpace = true
+	reader.options = options
+	reader.mapping = make(map[string]string)
+	for k, v := range mapping {
+		reader.mapping[strings.ToLower(strings.TrimSpace(k))] = strings.ToLower(v)
+	}
+	return reader
+}
+
+// Read the header row
+func (ardr *csvReader) readHeader() error {
+	if ardr.fields != nil {
+		return nil
+	}
+	header, err := ardr.reader.Read()
+	if err != nil {
+		return err
+	}
+	ardr.fields = make([]string, len(header))
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if name, ok := ardr.mapping[h]; ok {
+			ardr.fields[i] = name
+		} else {
+			ardr.fields[i] = strings.ReplaceAll(h, " ", "_")
+		}
+	}
+	return nil
+}
+
+// Get the field names of the columns
+func (ardr *csvReader) Fields() ([]string, error) {
+	if err := ardr.readHeader(); err != nil {
+		return nil, err
+	}
+	return ardr.fields, nil
+}
+
+func (ardr *csvReader) ReadRecord() (ADIFRecord, error) {
+	if err := ardr.readHeader(); err != nil {
+		return nil, err
+	}
+	for {
+		row, err := ardr.reader.Read()
+		if err != nil {
+			return nil, err
+		}
+		record := NewADIFRecord()
+		for i, v := range row {
+			v = strings.TrimSpace(v)
+			if i >= len(ardr.fields) || ardr.fields[i] == "" || v == "" {
+				continue
+			}
+			value, err := ardr.options.parseValue(ardr.fields[i], v)
+			if err != nil {
+				line, _ := ardr.reader.FieldPos(i)
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			record.SetValue(ardr.fields[i], value)
+		}
+		// Skip empty rows
+		if len(record.values) == 0 {
+			continue
+		}
+		ardr.records++
+		return record, nil
+	}
+}
+
+func (ardr *csvReader) RecordCount() int {
+	return ardr.records
+}
+
+// Iterate over the records
+func (ardr *csvReader) All() iter.Seq2[ADIFRecord, error] {
+	return AllRecords(ardr)
+}
@@ -0,0 +1,122 @@
+package adifparser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCSVWriterColumns(t *testing.T) {
+	testData := "<call:4>W1AW<qso_date:8>20230624<time_on:4>1805<mode:2>CW" +
+		"<app_x_note:5>a,\"b\"<eor>" +
+		"<call:5>JA1XX<qso_date:8>20230625<time_on:6>000130<band:3>20m<eor>"
+
+	var buf strings.Builder
+	writer := NewCSVWriter(&buf, nil, CSVOptions{DateFormat: "2006-01-02", TimeFormat: "15:04"})
+	// The columns are not chosen without a record
+	if err := writer.Flush(); err != nil || buf.Len() != 0 {
+		t.Fatalf("Unexpected output %q (%v)", buf.String(), err)
+	}
+	for record, err := range NewADIFReader(strings.NewReader(testData)).All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.WriteRecord(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	expected := "call,band,mode,qso_date,time_on,app_x_note\n" +
+		"W1AW,,CW,2023-06-24,18:05,\"a,\"\"b\"\"\"\n" +
+		"JA1XX,20m,,2023-06-25,00:01,\n"
+	if buf.String() != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, buf.String())
+	}
+
+	// Fields after the columns are chosen are not dropped silently
+	record := NewADIFRecord()
+	record.SetValue("call", "K1ABC")
+	if err := writer.WriteRecord(record); err != nil {
+		t.Fatal(err)
+	}
+	record.SetValue("gridsquare", "FN42")
+	if err := writer.WriteRecord(record); !errors.Is(err, ErrCSVColumn) {
+		t.Fatalf("Expected %v, got %v", ErrCSVColumn, err)
+	}
+}
+
+func TestCSVReader(t *testing.T) {
+	data := "Callsign;Date;UTC;Band;Mode;Notes;Ignored\n" +
+		"w1aw;24/06/2023;18:05;20m;CW;first;x\n" +
+		";;;;;;\n" +
+		"JA1XX;25/06/2023;00:01;40m;SSB\n" +
+		"K1ABC;2023-06-26;00:02;40m;SSB;;\n"
+	mapping := map[string]string{
+		"callsign": "call", "DATE": "qso_date", "UTC": "time_on",
+		"Notes": "comment", "Ignored": "",
+	}
+	reader := NewCSVReader(strings.NewReader(data), mapping,
+		CSVOptions{Comma: ';', DateFormat: "02/01/2006", TimeFormat: "15:04"})
+	fields, err := reader.Fields()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(fields, ",") != "call,qso_date,time_on,band,mode,comment," {
+		t.Fatalf("Unexpected fields %v", fields)
+	}
+
+	expected := []map[string]string{
+		{"call": "w1aw", "qso_date": "20230624", "time_on": "1805",
+			"band": "20m", "mode": "CW", "comment": "first"},
+		{"call": "JA1XX", "qso_date": "20230625", "time_on": "0001",
+			"band": "40m", "mode": "SSB"},
+	}
+	for _, exp := range expected {
+		record, err := reader.ReadRecord()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(record.GetFields()) != len(exp) {
+			t.Fatalf("Expected %d fields, got %v", len(exp), record.GetFields())
+		}
+		for k, v := range exp {
+			if got, err := record.GetValue(k); err != nil || got != v {
+				t.Fatalf("Field %s: expected %q, got %q (%v)", k, v, got, err)
+			}
+		}
+	}
+	if _, err := reader.ReadRecord(); !errors.Is(err, ErrCSVValue) {
+		t.Fatalf("Expected %v, got %v", ErrCSVValue, err)
+	}
+	if reader.RecordCount() != 2 {
+		t.Fatalf("Expected 2 records, got %d", reader.RecordCount())
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	testData := "<call:4>W1AW<qso_date:8>20230624<time_on:6>180512" +
+		"<freq:6>14.025<app_x_note:11>hello world<eor>"
+	record, err := NewADIFReader(strings.NewReader(testData)).ReadRecord()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf strings.Builder
+	options := CSVOptions{Comma: '\t'}
+	writer := NewCSVWriter(&buf, []string{"CALL", "qso_date", "time_on", "freq", "app_x_note"}, options)
+	if err := writer.WriteRecord(record); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := NewCSVReader(strings.NewReader(buf.String()), nil, options).ReadRecord()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ToString() != record.ToString() {
+		t.Fatalf("Expected %s, got %s", record.ToString(), got.ToString())
+	}
+}
@@ -0,0 +1,240 @@
+package adifparser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const EQSLAPI string = "https://www.eqsl.cc/qslcard/DownloadInBox.cfm"
+
+// Errors
+var ErrEQSLHTTPStatus = errors.New("eQSL HTTP status error")
+var ErrEQSLAuth = errors.New("eQSL authentication failed")
+var ErrEQSLDownload = errors.New("eQSL download failed")
+
+type EQSLClient interface {
+	// Implement reader
+	Read([]byte) (int, error)
+	Close() error
+}
+
+// Query options for the eQSL inbox download
+// Empty strings and zero values are not sent
+type EQSLQueryOptions struct {
+	// QSLs received since the date/time (RcvdSince), "YYYYMMDD" or "YYYYMMDDHHMM"
+	RcvdSince string
+	// QTH nickname of the account (QTHNickname)
+	QTHNickname string
+	// Confirmed QSLs only (ConfirmedOnly=1)
+	ConfirmedOnly bool
+}
+
+type eqslClientImpl struct {
+	// Creds
+	username string
+	password string
+	// Options
+	options EQSLQueryOptions
+	// Endpoint URL
+	baseURL string
+	// HTTP client
+	httpClient *http.Client
+	// User-Agent header value
+	userAgent string
+	// Timeout of the whole download (no timeout if zero)
+	timeout time.Duration
+	// Context of the requests
+	ctx context.Context
+	// Cancel function of the request context
+	cancel context.CancelFunc
+	// Body of the .adi file
+	body io.ReadCloser
+	// Error of opening the download, returned by the subsequent reads
+	openErr error
+}
+
+// Client configuration option
+type EQSLClientOption func(*eqslClientImpl)
+
+// Use the endpoint URL instead of EQSLAPI
+func WithEQSLBaseURL(baseURL string) EQSLClientOption {
+	return func(c *eqslClientImpl) {
+		c.baseURL = baseURL
+	}
+}
+
+// Use the HTTP client instead of http.DefaultClient
+func WithEQSLHTTPClient(client *http.Client) EQSLClientOption {
+	return func(c *eqslClientImpl) {
+		c.httpClient = client
+	}
+}
+
+// Use the User-Agent header value instead of DefaultUserAgent
+func WithEQSLUserAgent(userAgent string) EQSLClientOption {
+	return func(c *eqslClientImpl) {
+		c.userAgent = userAgent
+	}
+}
+
+// Limit the time of the whole download including reading the body
+func WithEQSLTimeout(timeout time.Duration) EQSLClientOption {
+	return func(c *eqslClientImpl) {
+		c.timeout = timeout
+	}
+}
+
+// Use the context for the requests
+func WithEQSLContext(ctx context.Context) EQSLClientOption {
+	return func(c *eqslClientImpl) {
+		c.ctx = ctx
+	}
+}
+
+// Create a new client
+func NewEQSLClient(username, password string, options EQSLQueryOptions,
+	opts ...EQSLClientOption) *eqslClientImpl {
+	client := &eqslClientImpl{}
+	client.username = username
+	client.password = password
+	client.options = options
+	client.baseURL = EQSLAPI
+	client.httpClient = http.DefaultClient
+	client.userAgent = DefaultUserAgent
+	client.ctx = context.Background()
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
+}
+
+// Read the .adi file
+func (c *eqslClientImpl) Read(p []byte) (int, error) {
+	if c.openErr != nil {
+		return 0, c.openErr
+	}
+	if c.body == nil {
+		if err := c.Open(c.ctx); err != nil {
+			c.openErr = err
+			return 0, err
+		}
+	}
+	return c.body.Read(p)
+}
+
+func (c *eqslClientImpl) Close() error {
+	var err error
+	if c.body != nil {
+		err = c.body.Close()
+		c.body = nil
+	}
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+	return err
+}
+
+// Link to the generated .adi file in the DownloadInBox result page
+var eqslADILink = regexp.MustCompile(`(?i)href\s*=\s*"([^"]+\.adi)"`)
+
+// Error message in the DownloadInBox result page
+var eqslErrorMessage = regexp.MustCompile(`(?i)error:\s*([^<\r\n]*)`)
+
+// Request the inbox download, then open the generated .adi file
+// Read calls Open with the configured context if not yet opened
+func (c *eqslClientImpl) Open(ctx context.Context) error {
+	if c.timeout > 0 {
+		ctx, c.cancel = context.WithTimeout(ctx, c.timeout)
+	}
+	requri, err := url.Parse(c.baseURL)
+	if err != nil {
+		c.Close()
+		return err
+	}
+	requri.RawQuery = makeQueryString(c.getParams())
+	adiflog.Printf("eQSL Requesting: %s\n", redactPassword(requri))
+	page, err := c.get(ctx, requri)
+	if err != nil {
+		c.Close()
+		return err
+	}
+	content, err := io.ReadAll(io.LimitReader(page.Body, 1<<20))
+	page.Body.Close()
+	if err != nil {
+		c.Close()
+		return err
+	}
+
+	link := eqslADILink.FindSubmatch(content)
+	if link == nil {
+		c.Close()
+		if bContainsCI(content, []byte("You have no log entries")) {
+			// Empty inbox
+			c.body = io.NopCloser(strings.NewReader(""))
+			return nil
+		}
+		if bContainsCI(content, []byte("password")) && bContainsCI(content, []byte("error")) {
+			return ErrEQSLAuth
+		}
+		if m := eqslErrorMessage.FindSubmatch(content); m != nil {
+			return fmt.Errorf("%w: %s", ErrEQSLDownload, strings.TrimSpace(string(m[1])))
+		}
+		return fmt.Errorf("%w: no .adi link found", ErrEQSLDownload)
+	}
+	adiuri, err := requri.Parse(html.UnescapeString(string(link[1])))
+	if err != nil {
+		c.Close()
+		return err
+	}
+	resp, err := c.get(ctx, adiuri)
+	if err != nil {
+		c.Close()
+		return err
+	}
+	c.body = resp.Body
+	return nil
+}
+
+// Send a GET request and check the status
+func (c *eqslClientImpl) get(ctx context.Context, u *url.URL) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrEQSLHTTPStatus, resp.Status)
+	}
+	return resp, nil
+}
+
+func (c *eqslClientImpl) getParams() map[string]string {
+	params := map[string]string{
+		"UserName": c.username,
+		"Password": c.password,
+	}
+	if c.options.RcvdSince != "" {
+		params["RcvdSince"] = c.options.RcvdSince
+	}
+	if c.options.QTHNickname != "" {
+		params["QTHNickname"] = c.options.QTHNickname
+	}
+	if c.options.ConfirmedOnly {
+		params["ConfirmedOnly"] = "1"
+	}
+	return params
+}
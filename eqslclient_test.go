@@ -0,0 +1,84 @@
+package adifparser
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func newEQSLTestServer(t *testing.T, page string) *httptest.Server {
+	data, err := os.ReadFile("testdata/wsjtx.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/qslcard/DownloadInBox.cfm", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("UserName") != "u" || q.Get("Password") != "p" ||
+			q.Get("RcvdSince") != "201505010000" || q.Get("QTHNickname") != "Home" ||
+			q.Get("ConfirmedOnly") != "1" {
+			t.Errorf("Unexpected query %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(page))
+	})
+	mux.HandleFunc("/downloadedfiles/u123.adi", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	})
+	return httptest.NewServer(mux)
+}
+
+func readEQSL(t *testing.T, page string) (int, error) {
+	server := newEQSLTestServer(t, page)
+	defer server.Close()
+
+	c := NewEQSLClient("u", "p", EQSLQueryOptions{
+		RcvdSince:     "201505010000",
+		QTHNickname:   "Home",
+		ConfirmedOnly: true,
+	}, WithEQSLBaseURL(server.URL+"/qslcard/DownloadInBox.cfm"))
+	defer c.Close()
+	reader := NewADIFReader(c)
+	for _, err := range reader.All() {
+		if err != nil {
+			return reader.RecordCount(), err
+		}
+	}
+	return reader.RecordCount(), nil
+}
+
+func TestEQSLClient(t *testing.T) {
+	count, err := readEQSL(t, "<HTML><BODY>Your ADIF log file has been built.<BR>"+
+		`<LI><A HREF="../downloadedfiles/u123.adi">.ADI file</A></BODY></HTML>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 74 {
+		t.Fatalf("Expected 74 records, got %d", count)
+	}
+}
+
+func TestEQSLClientEmpty(t *testing.T) {
+	count, err := readEQSL(t, "<HTML><BODY>You have no log entries</BODY></HTML>")
+	if err != nil || count != 0 {
+		t.Fatalf("Expected no records, got %d (%v)", count, err)
+	}
+}
+
+func TestEQSLClientErrors(t *testing.T) {
+	if _, err := readEQSL(t, "<HTML><BODY>Error: No such Username/Password found"+
+		"</BODY></HTML>"); !errors.Is(err, ErrEQSLAuth) {
+		t.Fatalf("Expected %v, got %v", ErrEQSLAuth, err)
+	}
+	_, err := readEQSL(t, "<HTML><BODY>Error: Invalid QTHNickname</BODY></HTML>")
+	if !errors.Is(err, ErrEQSLDownload) || err.Error() != "eQSL download failed: Invalid QTHNickname" {
+		t.Fatalf("Expected %v, got %v", ErrEQSLDownload, err)
+	}
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	c := NewEQSLClient("u", "p", EQSLQueryOptions{}, WithEQSLBaseURL(server.URL))
+	if _, err := c.Read(make([]byte, 16)); !errors.Is(err, ErrEQSLHTTPStatus) {
+		t.Fatalf("Expected %v, got %v", ErrEQSLHTTPStatus, err)
+	}
+}
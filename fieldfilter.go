@@ -0,0 +1,116 @@
+package adifparser
+
+import (
+	"path"
+	"strings"
+)
+
+// Privacy-sensitive fields
+// which should usually be stripped before sharing a log
+var PrivacyFields = []string{"address", "email", "public_key"}
+
+// Field selection rules for field filtering
+// Names may be exact field names or glob patterns
+// (e.g. "app_lotw_*", "my_*"), matched case-insensitively
+// Types are ADIF data types (ADIFString, ADIFDate, etc.)
+// If any Include rule is given, only the fields matching
+// at least one Include rule are kept;
+// then the fields matching any Exclude rule are dropped
+type FieldFilter struct {
+	IncludeNames []string
+	IncludeTypes []int
+	ExcludeNames []string
+	ExcludeTypes []int
+}
+
+// ADIFReader wrapper which filters the fields of each record
+type fieldFilterADIFReader struct {
+	// Underlying reader
+	rdr ADIFReader
+	// Filtering rules
+	filter FieldFilter
+}
+
+// Create a new field filtering reader
+func NewFieldFilterADIFReader(r ADIFReader, filter FieldFilter) *fieldFilterADIFReader {
+	reader := &fieldFilterADIFReader{}
+	reader.rdr = r
+	reader.filter = filter
+	reader.filter.IncludeNames = lowerNames(filter.IncludeNames)
+	reader.filter.ExcludeNames = lowerNames(filter.ExcludeNames)
+	return reader
+}
+
+func (ardr *fieldFilterADIFReader) ReadRecord() (ADIFRecord, error) {
+	record, err := ardr.rdr.ReadRecord()
+	if err != nil {
+		return nil, err
+	}
+	ardr.filter.Apply(record)
+	return record, nil
+}
+
+func (ardr *fieldFilterADIFReader) RecordCount() int {
+	return ardr.rdr.RecordCount()
+}
+
+// Delete the fields not selected by the filter from the record
+func (f FieldFilter) Apply(record ADIFRecord) {
+	for _, name := range record.GetFields() {
+		if !f.Selects(name) {
+			record.DeleteField(name)
+		}
+	}
+}
+
+// Check whether the field is kept by the filter
+func (f FieldFilter) Selects(name string) bool {
+	name = strings.ToLower(name)
+	if len(f.IncludeNames) > 0 || len(f.IncludeTypes) > 0 {
+		if !matchFieldName(f.IncludeNames, name) &&
+			!matchFieldType(f.IncludeTypes, name) {
+			return false
+		}
+	}
+	if matchFieldName(f.ExcludeNames, name) ||
+		matchFieldType(f.ExcludeTypes, name) {
+		return false
+	}
+	return true
+}
+
+// Match a field name against a list of names or glob patterns
+func matchFieldName(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if p == name {
+			return true
+		}
+		if matched, err := path.Match(strings.ToLower(p), name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Match a field datatype against a list of datatypes
+// Only the standard fields have known datatypes
+func matchFieldType(types []int, name string) bool {
+	info, ok := ADIFfieldInfo[name]
+	if !ok {
+		return false
+	}
+	for _, t := range types {
+		if info.datatype == t {
+			return true
+		}
+	}
+	return false
+}
+
+func lowerNames(names []string) []string {
+	lowered := make([]string, len(names))
+	for i, n := range names {
+		lowered[i] = strings.ToLower(n)
+	}
+	return lowered
+}
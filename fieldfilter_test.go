@@ -0,0 +1,56 @@
+package adifparser
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func testFieldFilter(t *testing.T, filter FieldFilter, expected []string) {
+	testData := "<CALL:4>W1AW<QSO_DATE:8>20150501<EMAIL:11>w1aw@arrl.o" +
+		"<APP_LOTW_MODEGROUP:4>DATA<MY_GRIDSQUARE:4>CM97<ADDRESS:3>xyz<EOR>"
+	reader := NewFieldFilterADIFReader(
+		NewADIFReader(strings.NewReader(testData)), filter)
+
+	record, err := reader.ReadRecord()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fieldNames := record.GetFields()
+	sort.Strings(fieldNames)
+	sort.Strings(expected)
+	if strings.Join(fieldNames, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Expected fields %v, got %v", expected, fieldNames)
+	}
+	if reader.RecordCount() != 1 {
+		t.Fatalf("Expected record count 1, got %d", reader.RecordCount())
+	}
+	if _, err = reader.ReadRecord(); err != io.EOF {
+		t.Fatalf("Expected %v, got %v", io.EOF, err)
+	}
+}
+
+func TestFieldFilterExcludePrivacy(t *testing.T) {
+	testFieldFilter(t, FieldFilter{ExcludeNames: PrivacyFields},
+		[]string{"call", "qso_date", "app_lotw_modegroup", "my_gridsquare"})
+}
+
+func TestFieldFilterExcludeGlob(t *testing.T) {
+	testFieldFilter(t, FieldFilter{ExcludeNames: []string{"APP_*", "my_*"}},
+		[]string{"call", "qso_date", "email", "address"})
+}
+
+func TestFieldFilterInclude(t *testing.T) {
+	testFieldFilter(t, FieldFilter{
+		IncludeNames: []string{"call", "app_lotw_*"},
+		IncludeTypes: []int{ADIFDate}},
+		[]string{"call", "qso_date", "app_lotw_modegroup"})
+}
+
+func TestFieldFilterIncludeExclude(t *testing.T) {
+	testFieldFilter(t, FieldFilter{
+		IncludeTypes: []int{ADIFString},
+		ExcludeNames: PrivacyFields},
+		[]string{"call", "my_gridsquare"})
+}
@@ -1,3 +1,7 @@
 module github.com/jj1bdx/adifparser
 
-go 1.20
+go 1.23
+
+require software.sslmate.com/src/go-pkcs12 v0.5.0
+
+require golang.org/x/crypto v0.11.0 // indirect
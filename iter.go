@@ -0,0 +1,86 @@
+package adifparser
+
+import (
+	"context"
+	"io"
+	"iter"
+)
+
+// Iterate over all the records of an ADIFReader
+// Each record is yielded with a nil error;
+// io.EOF ends the iteration without being yielded,
+// and any other error is yielded once with a nil record
+// before ending the iteration
+func AllRecords(r ADIFReader) iter.Seq2[ADIFRecord, error] {
+	return func(yield func(ADIFRecord, error) bool) {
+		for {
+			record, err := r.ReadRecord()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(record, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Result sent through a record channel
+type RecordResult struct {
+	Record ADIFRecord
+	Err    error
+}
+
+// Read all the records of an ADIFReader in a goroutine
+// and send them through the returned channel
+// The channel is closed after the last record (io.EOF is not sent),
+// after an error is sent, or when the context is cancelled;
+// the cancellation is checked between records,
+// so callers should check ctx.Err() after the channel is closed
+func RecordChannel(ctx context.Context, r ADIFReader) <-chan RecordResult {
+	ch := make(chan RecordResult)
+	go func() {
+		defer close(ch)
+		for record, err := range AllRecords(r) {
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case ch <- RecordResult{record, err}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// Iterators for each reader
+
+func (ardr *baseADIFReader) All() iter.Seq2[ADIFRecord, error] {
+	return AllRecords(ardr)
+}
+
+func (ardr *dedupeADIFReader) All() iter.Seq2[ADIFRecord, error] {
+	return AllRecords(ardr)
+}
+
+func (ardr *fieldFilterADIFReader) All() iter.Seq2[ADIFRecord, error] {
+	return AllRecords(ardr)
+}
+
+func (ardr *filterADIFReader) All() iter.Seq2[ADIFRecord, error] {
+	return AllRecords(ardr)
+}
+
+func (ardr *transformADIFReader) All() iter.Seq2[ADIFRecord, error] {
+	return AllRecords(ardr)
+}
+
+func (ardr *modeConvADIFReader) All() iter.Seq2[ADIFRecord, error] {
+	return AllRecords(ardr)
+}
@@ -0,0 +1,117 @@
+package adifparser
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	f, err := os.Open("testdata/wsjtx.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	reader := NewADIFReader(f)
+	count := 0
+	for record, err := range reader.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record == nil {
+			t.Fatal("Got nil record.")
+		}
+		count++
+	}
+	if count != 74 || reader.RecordCount() != 74 {
+		t.Fatalf("Expected 74 records, got %d (%d)", count, reader.RecordCount())
+	}
+}
+
+func TestAllBreak(t *testing.T) {
+	reader := NewDedupeADIFReader(strings.NewReader(
+		"<call:4>W1AW<eor><call:4>W1AW<eor><call:6>KF4MDV<eor><call:4>K1JT<eor>"))
+	calls := make([]string, 0, 2)
+	for record, err := range reader.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, _ := record.GetValue("call")
+		calls = append(calls, v)
+		if len(calls) == 2 {
+			break
+		}
+	}
+	if strings.Join(calls, ",") != "W1AW,KF4MDV" {
+		t.Fatalf("Unexpected calls %v", calls)
+	}
+	// The remaining record can still be read
+	if r, err := reader.ReadRecord(); err != nil || r == nil {
+		t.Fatalf("Expected a record, got %v (%v)", r, err)
+	}
+}
+
+func TestAllError(t *testing.T) {
+	reader := NewADIFReader(strings.NewReader("<call:4>W1AW<eor><call:x>W1AW<eor>"))
+	records, errs := 0, 0
+	for record, err := range reader.All() {
+		if err != nil {
+			if record != nil {
+				t.Fatal("Expected nil record with an error")
+			}
+			errs++
+			continue
+		}
+		records++
+	}
+	if records != 1 || errs != 1 {
+		t.Fatalf("Expected 1 record and 1 error, got %d and %d", records, errs)
+	}
+}
+
+func TestRecordChannel(t *testing.T) {
+	f, err := os.Open("testdata/lotw.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	count := 0
+	for result := range RecordChannel(context.Background(), NewADIFReader(f)) {
+		if result.Err != nil {
+			t.Fatal(result.Err)
+		}
+		count++
+	}
+	if count != 250 {
+		t.Fatalf("Expected 250 records, got %d", count)
+	}
+}
+
+func TestRecordChannelCancel(t *testing.T) {
+	f, err := os.Open("testdata/lotw.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := RecordChannel(ctx, NewADIFReader(f))
+	count := 0
+	for range ch {
+		count++
+		if count == 10 {
+			cancel()
+		}
+	}
+	if ctx.Err() == nil {
+		t.Fatal("Expected cancelled context")
+	}
+	// At most one more record may be in flight
+	if count > 11 {
+		t.Fatalf("Expected reading to stop after cancel, got %d records", count)
+	}
+}
@@ -0,0 +1,340 @@
+package adifparser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// JSON form of the records and the header
+//
+// A record is an object of the field names and the string values,
+// e.g. {"call":"W1AW","qso_date":"20230624"}.
+// In the typed form, the Number fields are JSON numbers
+// and the Boolean fields are JSON booleans.
+// The header is {"comment":"...","fields":{"adif_ver":"3.1.4"}}.
+// A JSON Lines log has a record on each line,
+// optionally preceded by {"header":{...}} on the first line.
+
+// Errors
+var ErrJSONRecord = errors.New("invalid JSON record")
+
+// ADIF header
+type ADIFHeader struct {
+	// Text outside the tags
+	Comment string `json:"comment,omitempty"`
+	// Header fields by the lowercase name
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// Get the header of an ADIF file
+// The header is read first if not yet read
+func (ardr *baseADIFReader) Header() ADIFHeader {
+	if !ardr.headerRead {
+		ardr.readHeader()
+	}
+	header := ADIFHeader{Comment: strings.TrimSpace(ardr.headerComment)}
+	if len(ardr.header) > 0 {
+		header.Fields = make(map[string]string, len(ardr.header))
+		for k, v := range ardr.header {
+			header.Fields[k] = v
+		}
+	}
+	return header
+}
+
+func (r *baseADIFRecord) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.values)
+}
+
+// Unmarshal a JSON object
+// Numbers are kept as written, booleans are set to Y or N,
+// and null values are skipped
+func (r *baseADIFRecord) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return fmt.Errorf("%w: %v", ErrJSONRecord, err)
+	}
+	if fields == nil {
+		return fmt.Errorf("%w: not an object", ErrJSONRecord)
+	}
+	values := make(map[string]string, len(fields))
+	for name, raw := range fields {
+		var v any
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&v); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrJSONRecord, name, err)
+		}
+		name = strings.ToLower(name)
+		switch v := v.(type) {
+		case nil:
+		case string:
+			values[name] = v
+		case json.Number:
+			values[name] = v.String()
+		case bool:
+			if v {
+				values[name] = "Y"
+			} else {
+				values[name] = "N"
+			}
+		default:
+			return fmt.Errorf("%w: %s is not a scalar", ErrJSONRecord, name)
+		}
+	}
+	r.values = values
+	r.annotations = make(map[string]string)
+	return nil
+}
+
+// Marshal a record with the typed values
+// Number and Boolean values not in the ADIF format are kept as strings,
+// and the Number values are normalized (e.g. "001" to 1)
+func MarshalTypedJSON(r ADIFRecord) ([]byte, error) {
+	fields := make(map[string]any)
+	for _, name := range r.GetFields() {
+		v, _ := r.GetValue(name)
+		fields[name] = typedJSONValue(name, v)
+	}
+	return json.Marshal(fields)
+}
+
+func typedJSONValue(name, value string) any {
+	switch ADIFfieldInfo[name].datatype {
+	case ADIFNumber:
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return json.Number(strconv.FormatFloat(f, 'f', -1, 64))
+		}
+	case ADIFBoolean:
+		if _, ok := ADIFfieldInfo[name]; !ok {
+			break
+		}
+		switch strings.ToUpper(strings.TrimSpace(value)) {
+		case "Y":
+			return true
+		case "N":
+			return false
+		}
+	}
+	return value
+}
+
+// Unmarshal a record from JSON
+func UnmarshalJSONRecord(data []byte) (ADIFRecord, error) {
+	record := NewADIFRecord()
+	if err := record.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// Writer of JSON Lines
+type jsonLinesWriter struct {
+	writer  *bufio.Writer
+	started bool
+	typed   bool
+	header  ADIFHeader
+}
+
+// Create a new JSON Lines writer
+func NewJSONLinesWriter(w io.Writer) *jsonLinesWriter {
+	writer := &jsonLinesWriter{}
+	writer.writer = bufio.NewWriter(w)
+	return writer
+}
+
+// Write the typed values
+func (writer *jsonLinesWriter) SetTyped(typed bool) {
+	writer.typed = typed
+}
+
+func (writer *jsonLinesWriter) SetComment(comment string) error {
+	if writer.started {
+		return ErrOutputStarted
+	}
+	writer.header.Comment = comment
+	return nil
+}
+
+// Set a header field such as adif_ver or programid
+func (writer *jsonLinesWriter) SetHeaderValue(name string, value string) error {
+	if writer.started {
+		return ErrOutputStarted
+	}
+	if writer.header.Fields == nil {
+		writer.header.Fields = make(map[string]string)
+	}
+	writer.header.Fields[string(bStrictToLower([]byte(name)))] = value
+	return nil
+}
+
+// Write the header line if set, once before any record
+func (writer *jsonLinesWriter) writeHeader() error {
+	if writer.started {
+		return nil
+	}
+	writer.started = true
+	if writer.header.Comment == "" && len(writer.header.Fields) == 0 {
+		return nil
+	}
+	return writer.writeLine(map[string]ADIFHeader{"header": writer.header})
+}
+
+func (writer *jsonLinesWriter) writeLine(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = writer.writer.Write(data)
+	return err
+}
+
+func (writer *jsonLinesWriter) WriteRecord(r ADIFRecord) error {
+	if err := writer.writeHeader(); err != nil {
+		return err
+	}
+	if writer.typed {
+		data, err := MarshalTypedJSON(r)
+		if err != nil {
+			return err
+		}
+		return writer.writeLine(json.RawMessage(data))
+	}
+	fields := make(map[string]string)
+	for _, name := range r.GetFields() {
+		fields[name], _ = r.GetValue(name)
+	}
+	return writer.writeLine(fields)
+}
+
+func (writer *jsonLinesWriter) Flush() error {
+	if err := writer.writeHeader(); err != nil {
+		return err
+	}
+	return writer.writer.Flush()
+}
+
+// Reader of JSON Lines
+type jsonLinesReader struct {
+	scanner *bufio.Scanner
+	// Current line number
+	line       int
+	header     ADIFHeader
+	headerRead bool
+	// First record line read with the header
+	pending []byte
+	records int
+}
+
+// Maximum length of a JSON Lines line
+const jsonLinesMaxLine = 1 << 20
+
+// Create a new JSON Lines reader
+func NewJSONLinesReader(r io.Reader) *jsonLinesReader {
+	reader := &jsonLinesReader{}
+	reader.scanner = bufio.NewScanner(r)
+	reader.scanner.Buffer(make([]byte, 0, 4096), jsonLinesMaxLine)
+	return reader
+}
+
+// Read a non-blank line
+func (ardr *jsonLinesReader) readLine() ([]byte, error) {
+	if ardr.pending != nil {
+		line := ardr.pending
+		ardr.pending = nil
+		return line, nil
+	}
+	for ardr.scanner.Scan() {
+		ardr.line++
+		line := bytes.TrimSpace(ardr.scanner.Bytes())
+		if len(line) > 0 {
+			return append([]byte{}, line...), nil
+		}
+	}
+	if err := ardr.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+// Read the header line if exists
+func (ardr *jsonLinesReader) readHeader() error {
+	if ardr.headerRead {
+		return nil
+	}
+	ardr.headerRead = true
+	line, err := ardr.readLine()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var h struct {
+		Header *ADIFHeader `json:"header"`
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(line, &fields) == nil && len(fields) == 1 &&
+		json.Unmarshal(line, &h) == nil && h.Header != nil {
+		ardr.header = *h.Header
+		return nil
+	}
+	ardr.pending = line
+	return nil
+}
+
+// Get the header
+func (ardr *jsonLinesReader) Header() (ADIFHeader, error) {
+	if err := ardr.readHeader(); err != nil {
+		return ADIFHeader{}, err
+	}
+	return ardr.header, nil
+}
+
+// Get a header field value
+func (ardr *jsonLinesReader) HeaderValue(name string) (string, error) {
+	if err := ardr.readHeader(); err != nil {
+		return "", err
+	}
+	if v, ok := ardr.header.Fields[string(bStrictToLower([]byte(name)))]; ok {
+		return v, nil
+	}
+	return "", ErrNoSuchField
+}
+
+func (ardr *jsonLinesReader) ReadRecord() (ADIFRecord, error) {
+	if err := ardr.readHeader(); err != nil {
+		return nil, err
+	}
+	line, err := ardr.readLine()
+	if err != nil {
+		return nil, err
+	}
+	record, err := UnmarshalJSONRecord(line)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", ardr.line, err)
+	}
+	ardr.records++
+	return record, nil
+}
+
+func (ardr *jsonLinesReader) RecordCount() int {
+	return ardr.records
+}
+
+// Iterate over the records
+func (ardr *jsonLinesReader) All() iter.Seq2[ADIFRecord, error] {
+	return AllRecords(ardr)
+}
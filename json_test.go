@@ -0,0 +1,137 @@
+package adifparser
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRecordJSON(t *testing.T) {
+	record := NewADIFRecord()
+	record.SetValue("call", "W1AW")
+	record.SetValue("freq", "14.025")
+	record.SetValue("qso_random", "Y")
+	record.SetValue("app_x_flag", "Y")
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"app_x_flag":"Y","call":"W1AW","freq":"14.025","qso_random":"Y"}`
+	if string(data) != expected {
+		t.Fatalf("Expected %s, got %s", expected, data)
+	}
+	typed, err := MarshalTypedJSON(record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `{"app_x_flag":"Y","call":"W1AW","freq":14.025,"qso_random":true}`
+	if string(typed) != expected {
+		t.Fatalf("Expected %s, got %s", expected, typed)
+	}
+
+	for _, d := range [][]byte{data, typed} {
+		got, err := UnmarshalJSONRecord(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.ToString() != record.ToString() {
+			t.Fatalf("Expected %s, got %s", record.ToString(), got.ToString())
+		}
+	}
+
+	got := NewADIFRecord()
+	if err := json.Unmarshal([]byte(`{"CALL":"JA1XX","srx":5,"notes":null}`), got); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := got.GetValue("call"); v != "JA1XX" || len(got.GetFields()) != 2 {
+		t.Fatalf("Unexpected record %s", got.ToString())
+	}
+	for _, bad := range []string{`[]`, `{"call":["W1AW"]}`, `null`, `{`} {
+		if _, err := UnmarshalJSONRecord([]byte(bad)); !errors.Is(err, ErrJSONRecord) {
+			t.Fatalf("%s: expected %v, got %v", bad, ErrJSONRecord, err)
+		}
+	}
+}
+
+func TestJSONLines(t *testing.T) {
+	var buf strings.Builder
+	writer := NewJSONLinesWriter(&buf)
+	writer.SetComment("Exported")
+	writer.SetHeaderValue("ADIF_VER", "3.1.4")
+	writer.SetTyped(true)
+
+	testData := "<call:4>W1AW<srx:3>001<eor><call:5>JA1XX<rx_pwr:3>abc<eor>"
+	for record, err := range NewADIFReader(strings.NewReader(testData)).All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.WriteRecord(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"header":{"comment":"Exported","fields":{"adif_ver":"3.1.4"}}}
+{"call":"W1AW","srx":1}
+{"call":"JA1XX","rx_pwr":"abc"}
+`
+	if buf.String() != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
+
+func TestJSONLinesReader(t *testing.T) {
+	data := `{"header":{"comment":"Exported","fields":{"adif_ver":"3.1.4"}}}
+
+{"call":"W1AW","srx":1}
+{"call":"JA1XX","qso_random":false}
+{"call":
+`
+	reader := NewJSONLinesReader(strings.NewReader(data))
+	if v, err := reader.HeaderValue("adif_ver"); err != nil || v != "3.1.4" {
+		t.Fatalf("Expected 3.1.4, got %q (%v)", v, err)
+	}
+	expected := []string{"<call:4>W1AW<srx:1>1", "<call:5>JA1XX<qso_random:1>N"}
+	for _, exp := range expected {
+		record, err := reader.ReadRecord()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if record.ToString() != exp {
+			t.Fatalf("Expected %s, got %s", exp, record.ToString())
+		}
+	}
+	if _, err := reader.ReadRecord(); !errors.Is(err, ErrJSONRecord) ||
+		!strings.HasPrefix(err.Error(), "line 5:") {
+		t.Fatalf("Expected %v at line 5, got %v", ErrJSONRecord, err)
+	}
+
+	// No header line
+	reader = NewJSONLinesReader(strings.NewReader(`{"call":"W1AW"}`))
+	header, err := reader.Header()
+	if err != nil || header.Comment != "" || header.Fields != nil {
+		t.Fatalf("Unexpected header %+v (%v)", header, err)
+	}
+	if record, err := reader.ReadRecord(); err != nil || record.ToString() != "<call:4>W1AW" {
+		t.Fatalf("Unexpected record (%v)", err)
+	}
+	if reader.RecordCount() != 1 {
+		t.Fatalf("Expected 1 record, got %d", reader.RecordCount())
+	}
+}
+
+func TestADIFHeaderJSON(t *testing.T) {
+	reader := NewADIFReader(strings.NewReader(
+		"Log export\n<adif_ver:5>3.1.4<programid:4>test<eoh>\n<call:4>W1AW<eor>"))
+	data, err := json.Marshal(reader.Header())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"comment":"Log export","fields":{"adif_ver":"3.1.4","programid":"test"}}`
+	if string(data) != expected {
+		t.Fatalf("Expected %s, got %s", expected, data)
+	}
+}
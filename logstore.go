@@ -0,0 +1,614 @@
+package adifparser
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Embedded log store
+//
+// The records are kept in an append-only data file of JSON lines:
+// {"id":1,"record":{...}} adds or replaces the record of the ID,
+// and {"id":1,"deleted":true} deletes it. The header line
+// {"next_id":2,"generation":N} at the start keeps the next record ID across
+// compactions, so the IDs of the deleted records are never reused, and has
+// a random generation changed by each compaction.
+// The index file (the data file name with ".idx") has the offsets
+// of the current records and the indexes by call, date, band/mode and DXCC,
+// with the data file size and generation indexed. On open, the data appended
+// after the indexed size is replayed, and a missing or broken index, or one
+// of another generation (e.g. of an interrupted compaction), is rebuilt.
+
+// Errors
+var ErrRecordNotFound = errors.New("record not found")
+var ErrLogStoreClosed = errors.New("log store closed")
+var ErrLogStoreData = errors.New("broken log store data")
+
+// Index file format version
+const logStoreIndexVersion = 1
+
+// Data file line
+type logStoreEntry struct {
+	ID      uint64          `json:"id,omitempty"`
+	Record  *baseADIFRecord `json:"record,omitempty"`
+	Deleted bool            `json:"deleted,omitempty"`
+	// Next record ID and data file generation of the header line (without ID)
+	NextID     uint64 `json:"next_id,omitempty"`
+	Generation uint64 `json:"generation,omitempty"`
+}
+
+// Index file content
+type logStoreIndex struct {
+	Version int `json:"version"`
+	// Data file size indexed
+	Size       int64  `json:"size"`
+	NextID     uint64 `json:"next_id"`
+	Generation uint64 `json:"generation"`
+	// Data file offsets of the current records
+	Offsets map[uint64]int64 `json:"offsets"`
+	// Record IDs by key
+	Call     map[string][]uint64 `json:"call"`
+	Date     map[string][]uint64 `json:"date"`
+	BandMode map[string][]uint64 `json:"band_mode"`
+	DXCC     map[string][]uint64 `json:"dxcc"`
+}
+
+// Index keys of a record
+type logStoreKeys struct {
+	call     string
+	date     string
+	bandMode string
+	dxcc     string
+}
+
+func recordStoreKeys(r ADIFRecord) logStoreKeys {
+	get := func(name string) string {
+		v, _ := r.GetValue(name)
+		return strings.TrimSpace(v)
+	}
+	return logStoreKeys{
+		call:     strings.ToUpper(get("call")),
+		date:     get("qso_date"),
+		bandMode: strings.ToLower(RecordBand(r)) + "/" + strings.ToUpper(get("mode")),
+		dxcc:     get("dxcc"),
+	}
+}
+
+// Log store
+// The methods are safe for concurrent use
+type logStore struct {
+	mu    sync.Mutex
+	path  string
+	data  *os.File
+	index logStoreIndex
+	// Index keys by record ID
+	keys map[uint64]logStoreKeys
+}
+
+// Open a log store, creating the data file if not exists
+func OpenLogStore(path string) (*logStore, error) {
+	data, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s := &logStore{path: path, data: data}
+	if err := s.loadIndex(); err != nil {
+		data.Close()
+		return nil, err
+	}
+	if s.index.Size == 0 {
+		header := logStoreEntry{NextID: s.index.NextID, Generation: newLogStoreGeneration(0)}
+		if err := s.append(header); err != nil {
+			data.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Random data file generation other than the old one
+func newLogStoreGeneration(old uint64) uint64 {
+	for {
+		if g := rand.Uint64(); g != 0 && g != old {
+			return g
+		}
+	}
+}
+
+// Generation in the header line of the data file (0 if none)
+func (s *logStore) dataGeneration() (uint64, error) {
+	if _, err := s.data.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	line, err := bufio.NewReader(s.data).ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	var entry logStoreEntry
+	if json.Unmarshal(line, &entry) != nil || entry.ID != 0 {
+		return 0, nil
+	}
+	return entry.Generation, nil
+}
+
+func (s *logStore) indexPath() string {
+	return s.path + ".idx"
+}
+
+func (s *logStore) resetIndex() {
+	s.index = logStoreIndex{
+		Version:  logStoreIndexVersion,
+		NextID:   1,
+		Offsets:  make(map[uint64]int64),
+		Call:     make(map[string][]uint64),
+		Date:     make(map[string][]uint64),
+		BandMode: make(map[string][]uint64),
+		DXCC:     make(map[string][]uint64),
+	}
+	s.keys = make(map[uint64]logStoreKeys)
+}
+
+// Load the index file and replay the data not indexed
+func (s *logStore) loadIndex() error {
+	s.resetIndex()
+	info, err := s.data.Stat()
+	if err != nil {
+		return err
+	}
+	generation, err := s.dataGeneration()
+	if err != nil {
+		return err
+	}
+	if content, err := os.ReadFile(s.indexPath()); err == nil {
+		var index logStoreIndex
+		if json.Unmarshal(content, &index) == nil &&
+			index.Version == logStoreIndexVersion && index.Size <= info.Size() &&
+			index.Generation == generation && index.Offsets != nil {
+			s.index = index
+			s.restoreKeys()
+		} else {
+			adiflog.Printf("Log store index %s is broken, rebuilding", s.indexPath())
+		}
+	}
+	return s.replay(s.index.Size)
+}
+
+// Restore the keys by record ID from the indexes
+func (s *logStore) restoreKeys() {
+	for _, m := range []*map[string][]uint64{
+		&s.index.Call, &s.index.Date, &s.index.BandMode, &s.index.DXCC} {
+		if *m == nil {
+			*m = make(map[string][]uint64)
+		}
+	}
+	for id := range s.index.Offsets {
+		s.keys[id] = logStoreKeys{}
+	}
+	set := func(m map[string][]uint64, f func(*logStoreKeys, string)) {
+		for key, ids := range m {
+			for _, id := range ids {
+				k := s.keys[id]
+				f(&k, key)
+				s.keys[id] = k
+			}
+		}
+	}
+	set(s.index.Call, func(k *logStoreKeys, v string) { k.call = v })
+	set(s.index.Date, func(k *logStoreKeys, v string) { k.date = v })
+	set(s.index.BandMode, func(k *logStoreKeys, v string) { k.bandMode = v })
+	set(s.index.DXCC, func(k *logStoreKeys, v string) { k.dxcc = v })
+}
+
+// Replay the data file from the offset
+// An incomplete last line (e.g. by a crash) is truncated
+func (s *logStore) replay(offset int64) error {
+	if _, err := s.data.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(s.data)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				adiflog.Printf("Log store %s: truncating an incomplete line", s.path)
+				if err := s.data.Truncate(offset); err != nil {
+					return err
+				}
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+		var entry logStoreEntry
+		if err := json.Unmarshal(line, &entry); err != nil ||
+			(entry.ID == 0 && entry.NextID == 0) {
+			return fmt.Errorf("%w: %s at offset %d", ErrLogStoreData, s.path, offset)
+		}
+		s.apply(entry, offset)
+		offset += int64(len(line))
+	}
+	s.index.Size = offset
+	_, err := s.data.Seek(offset, io.SeekStart)
+	return err
+}
+
+func addID(m map[string][]uint64, key string, id uint64) {
+	if key == "" {
+		return
+	}
+	ids := m[key]
+	i, found := slices.BinarySearch(ids, id)
+	if !found {
+		m[key] = slices.Insert(ids, i, id)
+	}
+}
+
+func removeID(m map[string][]uint64, key string, id uint64) {
+	ids := m[key]
+	if i, found := slices.BinarySearch(ids, id); found {
+		ids = slices.Delete(ids, i, i+1)
+		if len(ids) == 0 {
+			delete(m, key)
+		} else {
+			m[key] = ids
+		}
+	}
+}
+
+// Apply a data file line to the indexes
+func (s *logStore) apply(entry logStoreEntry, offset int64) {
+	if entry.ID == 0 {
+		if offset == 0 {
+			s.index.Generation = entry.Generation
+		}
+		if entry.NextID > s.index.NextID {
+			s.index.NextID = entry.NextID
+		}
+		return
+	}
+	if k, ok := s.keys[entry.ID]; ok {
+		removeID(s.index.Call, k.call, entry.ID)
+		removeID(s.index.Date, k.date, entry.ID)
+		removeID(s.index.BandMode, k.bandMode, entry.ID)
+		removeID(s.index.DXCC, k.dxcc, entry.ID)
+		delete(s.keys, entry.ID)
+		delete(s.index.Offsets, entry.ID)
+	}
+	if entry.ID >= s.index.NextID {
+		s.index.NextID = entry.ID + 1
+	}
+	if entry.Deleted || entry.Record == nil {
+		return
+	}
+	k := recordStoreKeys(entry.Record)
+	addID(s.index.Call, k.call, entry.ID)
+	addID(s.index.Date, k.date, entry.ID)
+	addID(s.index.BandMode, k.bandMode, entry.ID)
+	addID(s.index.DXCC, k.dxcc, entry.ID)
+	s.keys[entry.ID] = k
+	s.index.Offsets[entry.ID] = offset
+}
+
+// Append a line to the data file and apply it
+func (s *logStore) append(entry logStoreEntry) error {
+	if s.data == nil {
+		return ErrLogStoreClosed
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	if _, err := s.data.WriteAt(line, s.index.Size); err != nil {
+		return err
+	}
+	s.apply(entry, s.index.Size)
+	s.index.Size += int64(len(line))
+	return nil
+}
+
+// Copy a record into the internal type
+func copyStoreRecord(r ADIFRecord) *baseADIFRecord {
+	record := NewADIFRecord()
+	for _, name := range r.GetFields() {
+		v, _ := r.GetValue(name)
+		record.SetValue(name, v)
+	}
+	return record
+}
+
+// Add a record and return the new record ID
+func (s *logStore) Add(r ADIFRecord) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := s.index.NextID
+	if err := s.append(logStoreEntry{ID: id, Record: copyStoreRecord(r)}); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Add all the records of the reader
+// Returns the number of the records added
+func (s *logStore) Import(rdr ADIFReader) (int, error) {
+	n := 0
+	for record, err := range AllRecords(rdr) {
+		if err != nil {
+			return n, err
+		}
+		if _, err := s.Add(record); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Replace the record of the ID
+func (s *logStore) Update(id uint64, r ADIFRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.index.Offsets[id]; !ok {
+		return fmt.Errorf("%w: %d", ErrRecordNotFound, id)
+	}
+	return s.append(logStoreEntry{ID: id, Record: copyStoreRecord(r)})
+}
+
+// Delete the record of the ID
+func (s *logStore) Delete(id uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.index.Offsets[id]; !ok {
+		return fmt.Errorf("%w: %d", ErrRecordNotFound, id)
+	}
+	return s.append(logStoreEntry{ID: id, Deleted: true})
+}
+
+// Read the record at the offset
+func (s *logStore) readAt(offset int64) (ADIFRecord, error) {
+	if s.data == nil {
+		return nil, ErrLogStoreClosed
+	}
+	reader := bufio.NewReader(io.NewSectionReader(s.data, offset, s.index.Size-offset))
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v at offset %d", ErrLogStoreData, err, offset)
+	}
+	var entry logStoreEntry
+	if err := json.Unmarshal(line, &entry); err != nil || entry.Record == nil {
+		return nil, fmt.Errorf("%w: at offset %d", ErrLogStoreData, offset)
+	}
+	return entry.Record, nil
+}
+
+// Get the record of the ID
+func (s *logStore) Get(id uint64) (ADIFRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	offset, ok := s.index.Offsets[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrRecordNotFound, id)
+	}
+	return s.readAt(offset)
+}
+
+// Number of the records
+func (s *logStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.index.Offsets)
+}
+
+// Query of the log store
+// Empty conditions match all the records
+type LogQuery struct {
+	// CALL (case-insensitive)
+	Call string
+	// BAND and MODE (case-insensitive)
+	Band string
+	Mode string
+	// DXCC entity code
+	DXCC string
+	// QSO_DATE range, inclusive ("YYYYMMDD")
+	StartDate string
+	EndDate   string
+	// Other condition (e.g. by CompileQuery), evaluated on the records
+	Match RecordPredicate
+}
+
+// Whether the keys match the indexed conditions
+func (q *LogQuery) matchKeys(k logStoreKeys) bool {
+	band, mode, _ := strings.Cut(k.bandMode, "/")
+	return (q.Call == "" || strings.EqualFold(q.Call, k.call)) &&
+		(q.Band == "" || strings.EqualFold(q.Band, band)) &&
+		(q.Mode == "" || strings.EqualFold(q.Mode, mode)) &&
+		(q.DXCC == "" || q.DXCC == k.dxcc) &&
+		(q.StartDate == "" || k.date >= q.StartDate) &&
+		(q.EndDate == "" || k.date <= q.EndDate)
+}
+
+// Candidate IDs from the most selective index
+func (s *logStore) candidates(q *LogQuery) []uint64 {
+	switch {
+	case q.Call != "":
+		return s.index.Call[strings.ToUpper(q.Call)]
+	case q.DXCC != "":
+		return s.index.DXCC[q.DXCC]
+	case q.Band != "" && q.Mode != "":
+		return s.index.BandMode[strings.ToLower(q.Band)+"/"+strings.ToUpper(q.Mode)]
+	}
+	ids := make([]uint64, 0, len(s.index.Offsets))
+	if q.StartDate != "" || q.EndDate != "" {
+		for date, dateIDs := range s.index.Date {
+			if (q.StartDate == "" || date >= q.StartDate) &&
+				(q.EndDate == "" || date <= q.EndDate) {
+				ids = append(ids, dateIDs...)
+			}
+		}
+	} else {
+		for id := range s.index.Offsets {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Get the IDs of the records matching the query in ascending order
+func (s *logStore) Query(q LogQuery) ([]uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]uint64, 0, 16)
+	for _, id := range s.candidates(&q) {
+		if !q.matchKeys(s.keys[id]) {
+			continue
+		}
+		if q.Match != nil {
+			record, err := s.readAt(s.index.Offsets[id])
+			if err != nil {
+				return nil, err
+			}
+			if !q.Match(record) {
+				continue
+			}
+		}
+		result = append(result, id)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i] < result[j] })
+	return result, nil
+}
+
+// Write the records matching the query in the ID order
+// Returns the number of the records written
+func (s *logStore) Export(w ADIFWriter, q LogQuery) (int, error) {
+	ids, err := s.Query(q)
+	if err != nil {
+		return 0, err
+	}
+	for i, id := range ids {
+		record, err := s.Get(id)
+		if err != nil {
+			return i, err
+		}
+		if err := w.WriteRecord(record); err != nil {
+			return i, err
+		}
+	}
+	return len(ids), w.Flush()
+}
+
+// Write the index file
+func (s *logStore) saveIndex() error {
+	content, err := json.Marshal(&s.index)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.indexPath(), content)
+}
+
+// Sync the data file and write the index file
+func (s *logStore) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data == nil {
+		return ErrLogStoreClosed
+	}
+	if err := s.data.Sync(); err != nil {
+		return err
+	}
+	return s.saveIndex()
+}
+
+// Rewrite the data file with the current records only
+func (s *logStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data == nil {
+		return ErrLogStoreClosed
+	}
+	ids := make([]uint64, 0, len(s.index.Offsets))
+	for id := range s.index.Offsets {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	writer := bufio.NewWriter(tmp)
+	header, err := json.Marshal(logStoreEntry{
+		NextID: s.index.NextID, Generation: newLogStoreGeneration(s.index.Generation)})
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	writer.Write(append(header, '\n'))
+	for _, id := range ids {
+		record, err := s.readAt(s.index.Offsets[id])
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		line, err := json.Marshal(logStoreEntry{ID: id, Record: record.(*baseADIFRecord)})
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		writer.Write(append(line, '\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+
+	// Reopen and reindex; the next ID is kept by the header line,
+	// and the old index is rejected by the new generation
+	s.data.Close()
+	s.data, err = os.OpenFile(s.path, os.O_RDWR, 0o644)
+	if err != nil {
+		s.data = nil
+		return err
+	}
+	s.resetIndex()
+	if err := s.replay(0); err != nil {
+		return err
+	}
+	return s.saveIndex()
+}
+
+// Write the index file and close the store
+func (s *logStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data == nil {
+		return ErrLogStoreClosed
+	}
+	err := s.saveIndex()
+	if cerr := s.data.Close(); err == nil {
+		err = cerr
+	}
+	s.data = nil
+	return err
+}
@@ -0,0 +1,219 @@
+package adifparser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const logStoreTestData = "<call:4>W1AW<band:3>20m<mode:2>CW<qso_date:8>20230101<dxcc:3>291<eor>" +
+	"<call:5>JA1XX<band:3>20m<mode:3>SSB<qso_date:8>20230102<dxcc:3>339<eor>" +
+	"<call:4>w1aw<band:3>40m<mode:2>CW<qso_date:8>20230103<dxcc:3>291<eor>" +
+	"<call:4>K1XX<freq:5>7.025<mode:2>CW<qso_date:8>20230104<dxcc:3>291<eor>"
+
+func testLogStoreQuery(t *testing.T, s *logStore, q LogQuery, expected []uint64) {
+	t.Helper()
+	ids, err := s.Query(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != len(expected) {
+		t.Fatalf("Query %+v: expected %v, got %v", q, expected, ids)
+	}
+	for i := range ids {
+		if ids[i] != expected[i] {
+			t.Fatalf("Query %+v: expected %v, got %v", q, expected, ids)
+		}
+	}
+}
+
+func TestLogStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.jsonl")
+	s, err := OpenLogStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := s.Import(NewADIFReader(strings.NewReader(logStoreTestData)))
+	if err != nil || n != 4 {
+		t.Fatalf("Expected 4 records, got %d (%v)", n, err)
+	}
+
+	testLogStoreQuery(t, s, LogQuery{Call: "w1aw"}, []uint64{1, 3})
+	testLogStoreQuery(t, s, LogQuery{Call: "W1AW", Band: "20M"}, []uint64{1})
+	testLogStoreQuery(t, s, LogQuery{Band: "40m", Mode: "cw"}, []uint64{3, 4})
+	testLogStoreQuery(t, s, LogQuery{DXCC: "291", StartDate: "20230102"}, []uint64{3, 4})
+	testLogStoreQuery(t, s, LogQuery{StartDate: "20230102", EndDate: "20230103"}, []uint64{2, 3})
+	testLogStoreQuery(t, s, LogQuery{Mode: "CW"}, []uint64{1, 3, 4})
+	match, err := CompileQuery("call ~ ^K")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testLogStoreQuery(t, s, LogQuery{Match: match}, []uint64{4})
+
+	record, err := s.Get(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record.SetValue("band", "15m")
+	if err := s.Update(2, record); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(1); !errors.Is(err, ErrRecordNotFound) {
+		t.Fatalf("Expected %v, got %v", ErrRecordNotFound, err)
+	}
+	testLogStoreQuery(t, s, LogQuery{Band: "15m"}, []uint64{2})
+	testLogStoreQuery(t, s, LogQuery{Call: "W1AW"}, []uint64{3})
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopen with the index
+	s, err = OpenLogStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Len() != 3 {
+		t.Fatalf("Expected 3 records, got %d", s.Len())
+	}
+	testLogStoreQuery(t, s, LogQuery{Band: "15m", Mode: "SSB"}, []uint64{2})
+	if id, err := s.Add(NewADIFRecord()); err != nil || id != 5 {
+		t.Fatalf("Expected ID 5, got %d (%v)", id, err)
+	}
+
+	var buf strings.Builder
+	writer := NewADIFWriter(&buf)
+	if n, err := s.Export(writer, LogQuery{DXCC: "291"}); err != nil || n != 2 {
+		t.Fatalf("Expected 2 records, got %d (%v)", n, err)
+	}
+	expected := "<call:4>w1aw<band:3>40m<mode:2>CW<qso_date:8>20230103<dxcc:3>291<eor>\n" +
+		"<call:4>K1XX<freq:5>7.025<mode:2>CW<qso_date:8>20230104<dxcc:3>291<eor>\n"
+	if buf.String() != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, buf.String())
+	}
+
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	testLogStoreQuery(t, s, LogQuery{Mode: "CW"}, []uint64{3, 4})
+	if id, err := s.Add(NewADIFRecord()); err != nil || id != 6 {
+		t.Fatalf("Expected ID 6, got %d (%v)", id, err)
+	}
+	s.Close()
+	if _, err := s.Get(3); !errors.Is(err, ErrLogStoreClosed) {
+		t.Fatalf("Expected %v, got %v", ErrLogStoreClosed, err)
+	}
+}
+
+func TestLogStoreRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.jsonl")
+	s, err := OpenLogStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Import(NewADIFReader(strings.NewReader(logStoreTestData))); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	// Records added after the index was written, and an incomplete line
+	s.Add(NewADIFRecord())
+	s.Delete(4)
+	s.data.WriteAt([]byte(`{"id":9,"rec`), s.index.Size)
+	s.data.Close()
+	s.data = nil
+
+	s, err = OpenLogStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Len() != 4 {
+		t.Fatalf("Expected 4 records, got %d", s.Len())
+	}
+	testLogStoreQuery(t, s, LogQuery{DXCC: "291"}, []uint64{1, 3})
+	s.Close()
+
+	// Broken index
+	if err := os.WriteFile(path+".idx", []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err = OpenLogStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testLogStoreQuery(t, s, LogQuery{Call: "JA1XX"}, []uint64{2})
+	if id, err := s.Add(NewADIFRecord()); err != nil || id != 6 {
+		t.Fatalf("Expected ID 6, got %d (%v)", id, err)
+	}
+
+	// IDs of the deleted records are not reused after compaction
+	// even without the index
+	if err := s.Delete(6); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	if err := os.Remove(path + ".idx"); err != nil {
+		t.Fatal(err)
+	}
+	s, err = OpenLogStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if id, err := s.Add(NewADIFRecord()); err != nil || id != 7 {
+		t.Fatalf("Expected ID 7, got %d (%v)", id, err)
+	}
+}
+
+func TestLogStoreStaleIndex(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.jsonl")
+	s, err := OpenLogStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Import(NewADIFReader(strings.NewReader(logStoreTestData))); err != nil {
+		t.Fatal(err)
+	}
+	s.Delete(1)
+	if err := s.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	stale, err := os.ReadFile(path + ".idx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	// Larger than the data file indexed
+	for i := 0; i < 8; i++ {
+		s.Add(NewADIFRecord())
+	}
+	s.Close()
+
+	// The index before the compaction, as left by a crash before
+	// the new index was written, is not used for the compacted data
+	if err := os.WriteFile(path+".idx", stale, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err = OpenLogStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if s.Len() != 11 {
+		t.Fatalf("Expected 11 records, got %d", s.Len())
+	}
+	testLogStoreQuery(t, s, LogQuery{DXCC: "291"}, []uint64{3, 4})
+	if record, err := s.Get(2); err != nil || RecordValue(record, "call") != "JA1XX" {
+		t.Fatalf("Unexpected record %v (%v)", record, err)
+	}
+}
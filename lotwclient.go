@@ -2,20 +2,93 @@ package adifparser
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 )
 
 const LOTWAPI string = "https://lotw.arrl.org/lotwuser/lotwreport.adi"
 
+// DXCC credit query endpoint
+const LOTWDXCCCreditAPI string = "https://lotw.arrl.org/lotwuser/logbook/qslcards.php"
+
+// Default User-Agent header value
+const DefaultUserAgent string = "adifparser (+https://github.com/jj1bdx/adifparser)"
+
+// Errors
+var ErrLOTWHTTPStatus = errors.New("LoTW HTTP status error")
+var ErrLOTWAuth = errors.New("LoTW authentication failed")
+var ErrLOTWHTML = errors.New("LoTW returned an HTML page instead of ADIF")
+var ErrLOTWTruncated = errors.New("LoTW report truncated")
+
+// HTTP status error
+// Matches ErrLOTWHTTPStatus, and also ErrLOTWAuth for 401 and 403
+type LOTWStatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *LOTWStatusError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrLOTWHTTPStatus, e.Status)
+}
+
+func (e *LOTWStatusError) Is(target error) bool {
+	switch target {
+	case ErrLOTWHTTPStatus:
+		return true
+	case ErrLOTWAuth:
+		return e.StatusCode == http.StatusUnauthorized ||
+			e.StatusCode == http.StatusForbidden
+	}
+	return false
+}
+
 type LOTWClient interface {
 	// Implement reader
 	Read([]byte) (int, error)
 	Close() error
 }
 
+// Query options for the LoTW report
+// See https://lotw.arrl.org/lotw-help/developer-query-qsos-qsls/
+// Empty strings and zero values are not sent
+type LOTWQueryOptions struct {
+	// Report QSLs only (qso_qsl=yes) instead of all QSOs (qso_qsl=no)
+	QSLOnly bool
+	// QSLs received since the date/time (qso_qslsince),
+	// "YYYY-MM-DD" or "YYYY-MM-DD HH:MM:SS"; only with QSLOnly
+	QSLSince string
+	// QSOs received since the date/time (qso_qsorxsince); only without QSLOnly
+	QSORxSince string
+	// QSOs by own station callsign (qso_owncall)
+	OwnCall string
+	// QSOs with the worked station callsign (qso_callsign)
+	Callsign string
+	// QSOs of the mode (qso_mode)
+	Mode string
+	// QSOs of the band (qso_band)
+	Band string
+	// QSOs with the DXCC entity number (qso_dxcc)
+	DXCC string
+	// QSO date/time range (qso_startdate, qso_starttime,
+	// qso_enddate, qso_endtime), "YYYY-MM-DD" and "HH:MM:SS"
+	StartDate string
+	StartTime string
+	EndDate   string
+	EndTime   string
+	// Include own station details (qso_mydetail)
+	MyDetail bool
+	// Include QSL details (qso_qsldetail)
+	QSLDetail bool
+	// Include own callsign (qso_withown)
+	WithOwn bool
+}
+
 type lotwClientImpl struct {
 	// Creds
 	username string
@@ -25,34 +98,122 @@ type lotwClientImpl struct {
 	// Temporary read buffer
 	buf []byte
 	// Options
-	qsl_only      bool
-	qso_mydetail  bool
-	qso_qsldetail bool
-	qso_withown   bool
-	// TODO: state storage
+	options LOTWQueryOptions
+	// Endpoint URL
+	baseURL string
+	// DXCC account number for the DXCC credit query
+	// (if empty, the QSO/QSL report is queried)
+	creditAccount string
+	// HTTP client
+	httpClient *http.Client
+	// User-Agent header value
+	userAgent string
+	// Timeout of the whole download (no timeout if zero)
+	timeout time.Duration
+	// Context of the request
+	ctx context.Context
+	// Cancel function of the request context
+	cancel context.CancelFunc
+	// Whether <eoh> and <APP_LoTW_EOF> have been received
+	sawEOH bool
+	sawEOF bool
+	// Last bytes received, to find the markers across reads
+	tail []byte
+	// Error of opening the request, returned by the subsequent reads
+	openErr error
+}
+
+// Client configuration option
+type LOTWClientOption func(*lotwClientImpl)
+
+// Use the endpoint URL instead of LOTWAPI
+func WithLOTWBaseURL(baseURL string) LOTWClientOption {
+	return func(c *lotwClientImpl) {
+		c.baseURL = baseURL
+	}
+}
+
+// Use the HTTP client instead of http.DefaultClient
+func WithLOTWHTTPClient(client *http.Client) LOTWClientOption {
+	return func(c *lotwClientImpl) {
+		c.httpClient = client
+	}
+}
+
+// Use the User-Agent header value instead of DefaultUserAgent
+func WithLOTWUserAgent(userAgent string) LOTWClientOption {
+	return func(c *lotwClientImpl) {
+		c.userAgent = userAgent
+	}
+}
+
+// Limit the time of the whole download including reading the body
+func WithLOTWTimeout(timeout time.Duration) LOTWClientOption {
+	return func(c *lotwClientImpl) {
+		c.timeout = timeout
+	}
+}
+
+// Use the context for the request
+func WithLOTWContext(ctx context.Context) LOTWClientOption {
+	return func(c *lotwClientImpl) {
+		c.ctx = ctx
+	}
+}
+
+// Default query options
+func DefaultLOTWQueryOptions() LOTWQueryOptions {
+	return LOTWQueryOptions{
+		QSLOnly:   false,
+		MyDetail:  true,
+		QSLDetail: true,
+		WithOwn:   true,
+	}
 }
 
 // Create a new client
-func NewLOTWClient(username, password string) *lotwClientImpl {
+func NewLOTWClient(username, password string, opts ...LOTWClientOption) *lotwClientImpl {
+	return NewLOTWClientWithOptions(username, password, DefaultLOTWQueryOptions(), opts...)
+}
+
+// Create a new client with the query options
+func NewLOTWClientWithOptions(username, password string, options LOTWQueryOptions,
+	opts ...LOTWClientOption) *lotwClientImpl {
 	client := &lotwClientImpl{}
 	client.username = username
 	client.password = password
 	client.buf = make([]byte, 0, 1024)
+	client.options = options
+	client.baseURL = LOTWAPI
+	client.httpClient = http.DefaultClient
+	client.userAgent = DefaultUserAgent
+	client.ctx = context.Background()
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client
+}
 
-	// Default options
-	client.qsl_only = false
-	client.qso_mydetail = true
-	client.qso_qsldetail = true
-	client.qso_withown = true
+// Get the query options
+func (c *lotwClientImpl) Options() LOTWQueryOptions {
+	return c.options
+}
 
-	return client
+// Set the query options
+// Only effective before the first Read
+func (c *lotwClientImpl) SetOptions(options LOTWQueryOptions) {
+	c.options = options
 }
 
 // Read from socket
 func (c *lotwClientImpl) Read(p []byte) (int, error) {
+	if c.openErr != nil {
+		return 0, c.openErr
+	}
 	if c.httpResponse == nil {
-		if err := c.open(); err != nil {
+		if err := c.Open(c.ctx); err != nil {
 			// TODO: better logging
+			c.openErr = err
 			return 0, err
 		}
 	}
@@ -63,6 +224,7 @@ func (c *lotwClientImpl) Read(p []byte) (int, error) {
 		n = copy(p, usable)
 		return n, err
 	}
+	c.scanMarkers(storage[:n])
 	usable = append(usable, storage[:n]...)
 	max_len := len(usable)
 	if cap(p) < max_len {
@@ -78,51 +240,169 @@ func (c *lotwClientImpl) Read(p []byte) (int, error) {
 
 	n = copy(p, usable[:max_len])
 	if err == io.EOF && len(c.buf) == 0 {
+		// The DXCC credit report has no end markers
+		if c.creditAccount != "" {
+			return n, err
+		}
+		if !c.sawEOH {
+			return n, fmt.Errorf("%w: no <eoh>", ErrLOTWTruncated)
+		}
+		if !c.sawEOF {
+			return n, fmt.Errorf("%w: no <APP_LoTW_EOF>", ErrLOTWTruncated)
+		}
 		return n, err
 	}
 	return n, nil
 }
 
+// Look for the <eoh> and <APP_LoTW_EOF> markers in the received bytes
+func (c *lotwClientImpl) scanMarkers(b []byte) {
+	window := append(c.tail, b...)
+	if !c.sawEOH && bContainsCI(window, []byte("<eoh>")) {
+		c.sawEOH = true
+	}
+	if !c.sawEOF && bContainsCI(window, []byte("<app_lotw_eof>")) {
+		c.sawEOF = true
+	}
+	const tailLen = len("<app_lotw_eof>") - 1
+	if len(window) > tailLen {
+		window = window[len(window)-tailLen:]
+	}
+	c.tail = append([]byte(nil), window...)
+}
+
+// Check whether the response is an HTML page instead of ADIF
+func isHTMLResponse(resp *http.Response, head []byte) bool {
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return true
+	}
+	head = bytes.TrimSpace(head)
+	return bIndexCI(head, []byte("<!doctype html")) == 0 ||
+		bIndexCI(head, []byte("<html")) == 0
+}
+
 func (c *lotwClientImpl) Close() error {
+	var err error
 	if c.httpResponse != nil {
-		err := c.httpResponse.Body.Close()
+		err = c.httpResponse.Body.Close()
 		c.httpResponse = nil
-		return err
 	}
-	return nil
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+	return err
 }
 
-func (c *lotwClientImpl) open() error {
+// Send the request with the context
+// Read calls Open with the configured context if not yet opened
+func (c *lotwClientImpl) Open(ctx context.Context) error {
 	params := c.getParams()
-	requri, _ := url.Parse(LOTWAPI)
+	requri, err := url.Parse(c.baseURL)
+	if err != nil {
+		return err
+	}
 	requri.RawQuery = makeQueryString(params)
-	adiflog.Printf("LOTW Requesting: %s\n", requri.String())
-	resp, err := http.Get(requri.String())
+	adiflog.Printf("LOTW Requesting: %s\n", redactPassword(requri))
+	if c.timeout > 0 {
+		ctx, c.cancel = context.WithTimeout(ctx, c.timeout)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requri.String(), nil)
 	if err != nil {
+		c.Close()
+		return err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		c.Close()
 		return err
 	}
 	c.httpResponse = resp
+	if resp.StatusCode != http.StatusOK {
+		c.Close()
+		return &LOTWStatusError{resp.StatusCode, resp.Status}
+	}
+
+	// Check the beginning of the response
+	head := make([]byte, 512)
+	n, err := io.ReadFull(resp.Body, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		c.Close()
+		return err
+	}
+	head = head[:n]
+	if isHTMLResponse(resp, head) {
+		rest, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		page := append(head, rest...)
+		c.Close()
+		if bContainsCI(page, []byte("password incorrect")) {
+			return ErrLOTWAuth
+		}
+		return ErrLOTWHTML
+	}
+	c.scanMarkers(head)
+	c.buf = append(c.buf, head...)
 	return nil
 }
 
+// URL string without the password for logging
+func redactPassword(u *url.URL) string {
+	redacted := *u
+	query := redacted.Query()
+	for k := range query {
+		if strings.EqualFold(k, "password") {
+			query.Set(k, "xxxxx")
+			redacted.RawQuery = query.Encode()
+		}
+	}
+	return redacted.String()
+}
+
 func (c *lotwClientImpl) getParams() map[string]string {
+	if c.creditAccount != "" {
+		return map[string]string{
+			"login":    c.username,
+			"password": c.password,
+			"ac_acct":  c.creditAccount,
+		}
+	}
 	params := map[string]string{
 		"login":     c.username,
 		"password":  c.password,
 		"qso_query": "1",
 	}
-	if c.qsl_only {
+	opts := c.options
+	if opts.QSLOnly {
 		params["qso_qsl"] = "yes"
 	} else {
 		params["qso_qsl"] = "no"
 	}
-	if c.qso_mydetail {
+	optional := map[string]string{
+		"qso_qslsince":   opts.QSLSince,
+		"qso_qsorxsince": opts.QSORxSince,
+		"qso_owncall":    opts.OwnCall,
+		"qso_callsign":   opts.Callsign,
+		"qso_mode":       opts.Mode,
+		"qso_band":       opts.Band,
+		"qso_dxcc":       opts.DXCC,
+		"qso_startdate":  opts.StartDate,
+		"qso_starttime":  opts.StartTime,
+		"qso_enddate":    opts.EndDate,
+		"qso_endtime":    opts.EndTime,
+	}
+	for k, v := range optional {
+		if v != "" {
+			params[k] = v
+		}
+	}
+	if opts.MyDetail {
 		params["qso_mydetail"] = "yes"
 	}
-	if c.qso_qsldetail {
+	if opts.QSLDetail {
 		params["qso_qsldetail"] = "yes"
 	}
-	if c.qso_withown {
+	if opts.WithOwn {
 		params["qso_withown"] = "yes"
 	}
 	return params
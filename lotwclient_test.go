@@ -1,9 +1,14 @@
 package adifparser
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 type mockBody struct {
@@ -59,3 +64,201 @@ func TestReadChunked(t *testing.T) {
 		t.Fatalf("Expected %v, got %v.\n", testString, buf)
 	}
 }
+
+func TestGetParams(t *testing.T) {
+	c := NewLOTWClient("u", "p")
+	params := c.getParams()
+	expected := map[string]string{
+		"login": "u", "password": "p", "qso_query": "1", "qso_qsl": "no",
+		"qso_mydetail": "yes", "qso_qsldetail": "yes", "qso_withown": "yes",
+	}
+	if len(params) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, params)
+	}
+	for k, v := range expected {
+		if params[k] != v {
+			t.Fatalf("%s: expected %s, got %s", k, v, params[k])
+		}
+	}
+
+	opts := c.Options()
+	opts.QSLOnly = true
+	opts.QSLSince = "2015-06-02 21:02:09"
+	opts.OwnCall = "JJ1BDX"
+	opts.Band = "20M"
+	opts.DXCC = "339"
+	opts.StartDate = "2015-01-01"
+	opts.WithOwn = false
+	c.SetOptions(opts)
+	params = c.getParams()
+	expected = map[string]string{
+		"login": "u", "password": "p", "qso_query": "1", "qso_qsl": "yes",
+		"qso_qslsince": "2015-06-02 21:02:09", "qso_owncall": "JJ1BDX",
+		"qso_band": "20M", "qso_dxcc": "339", "qso_startdate": "2015-01-01",
+		"qso_mydetail": "yes", "qso_qsldetail": "yes",
+	}
+	if len(params) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, params)
+	}
+	for k, v := range expected {
+		if params[k] != v {
+			t.Fatalf("%s: expected %s, got %s", k, v, params[k])
+		}
+	}
+}
+
+func newLOTWTestServer(t *testing.T, filename string) *httptest.Server {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("login") != "u" || r.URL.Query().Get("password") != "p" {
+			t.Errorf("Unexpected query %s", r.URL.RawQuery)
+		}
+		if r.Header.Get("User-Agent") != "lotwtest/1.0" {
+			t.Errorf("Unexpected User-Agent %s", r.Header.Get("User-Agent"))
+		}
+		w.Write(data)
+	}))
+}
+
+func TestLOTWClientServer(t *testing.T) {
+	server := newLOTWTestServer(t, "testdata/lotw.adi")
+	defer server.Close()
+
+	c := NewLOTWClient("u", "p",
+		WithLOTWBaseURL(server.URL+"/lotwuser/lotwreport.adi"),
+		WithLOTWHTTPClient(server.Client()),
+		WithLOTWUserAgent("lotwtest/1.0"),
+		WithLOTWTimeout(10*time.Second))
+	defer c.Close()
+	reader := NewADIFReader(c)
+	for _, err := range reader.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if reader.RecordCount() != 250 {
+		t.Fatalf("Expected 250 records, got %d", reader.RecordCount())
+	}
+}
+
+func TestLOTWClientTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	c := NewLOTWClient("u", "p",
+		WithLOTWBaseURL(server.URL),
+		WithLOTWTimeout(50*time.Millisecond))
+	defer c.Close()
+	buf := make([]byte, 1024)
+	if _, err := c.Read(buf); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestLOTWClientContext(t *testing.T) {
+	server := newLOTWTestServer(t, "testdata/lotw.adi")
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := NewLOTWClient("u", "p", WithLOTWBaseURL(server.URL), WithLOTWContext(ctx))
+	defer c.Close()
+	buf := make([]byte, 1024)
+	if _, err := c.Read(buf); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func readLOTWFromServer(t *testing.T, handler http.HandlerFunc) (int, error) {
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	c := NewLOTWClient("u", "p", WithLOTWBaseURL(server.URL))
+	defer c.Close()
+	reader := NewADIFReader(c)
+	for _, err := range reader.All() {
+		if err != nil {
+			return reader.RecordCount(), err
+		}
+	}
+	return reader.RecordCount(), nil
+}
+
+func TestLOTWClientErrors(t *testing.T) {
+	report, err := os.ReadFile("testdata/lotw.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	noEOF, err := os.ReadFile("testdata/lotw_empty_no_eof.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		expected error
+	}{
+		{"complete", func(w http.ResponseWriter, r *http.Request) {
+			w.Write(report)
+		}, nil},
+		{"status", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "oops", http.StatusInternalServerError)
+		}, ErrLOTWHTTPStatus},
+		{"forbidden", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+		}, ErrLOTWAuth},
+		{"password", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<!DOCTYPE html><html><body>" +
+				"Username/password incorrect</body></html>"))
+		}, ErrLOTWAuth},
+		{"html", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("\n<HTML><body>Maintenance</body></HTML>"))
+		}, ErrLOTWHTML},
+		{"no eof", func(w http.ResponseWriter, r *http.Request) {
+			w.Write(noEOF)
+		}, ErrLOTWTruncated},
+		{"no eoh", func(w http.ResponseWriter, r *http.Request) {
+			w.Write(report[:200])
+		}, ErrLOTWTruncated},
+		{"truncated", func(w http.ResponseWriter, r *http.Request) {
+			w.Write(report[:len(report)/2])
+		}, ErrLOTWTruncated},
+	}
+	for _, test := range tests {
+		count, err := readLOTWFromServer(t, test.handler)
+		if !errors.Is(err, test.expected) || (err == nil) != (test.expected == nil) {
+			t.Fatalf("%s: expected %v, got %v", test.name, test.expected, err)
+		}
+		if test.expected == nil && count != 250 {
+			t.Fatalf("%s: expected 250 records, got %d", test.name, count)
+		}
+	}
+}
+
+func TestLOTWClientSingleRequest(t *testing.T) {
+	requests := 0
+	_, err := readLOTWFromServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+	var statusErr *LOTWStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status error, got %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("Expected 1 request, got %d", requests)
+	}
+}
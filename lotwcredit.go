@@ -0,0 +1,147 @@
+package adifparser
+
+import (
+	"sort"
+	"strings"
+)
+
+// Award credit names for DXCC
+const (
+	CreditDXCC      = "DXCC"
+	CreditDXCCBand  = "DXCC_BAND"
+	CreditDXCCMode  = "DXCC_MODE"
+	CreditChallenge = "DXCC_CHALLENGE"
+)
+
+// Credit of an award with the QSL media
+// (e.g. "DXCC:LOTW&CARD" is {"DXCC", ["LOTW", "CARD"]})
+type AwardCredit struct {
+	Award string
+	Media []string
+}
+
+// List of award credits, as in CREDIT_SUBMITTED and CREDIT_GRANTED
+type CreditList []AwardCredit
+
+// Create a new client for the DXCC credit query
+// account is the DXCC account number of the user
+// The records have CREDIT_GRANTED or APP_LoTW_CREDIT_GRANTED
+func NewLOTWDXCCCreditClient(username, password, account string,
+	opts ...LOTWClientOption) *lotwClientImpl {
+	opts = append([]LOTWClientOption{WithLOTWBaseURL(LOTWDXCCCreditAPI)}, opts...)
+	client := NewLOTWClient(username, password, opts...)
+	client.creditAccount = account
+	return client
+}
+
+// Parse a credit list
+// Award names and media are set to uppercase
+func ParseCreditList(s string) CreditList {
+	list := make(CreditList, 0, 4)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		award, media, _ := strings.Cut(item, ":")
+		credit := AwardCredit{Award: strings.ToUpper(strings.TrimSpace(award))}
+		if media != "" {
+			for _, m := range strings.Split(media, "&") {
+				credit.Media = append(credit.Media, strings.ToUpper(strings.TrimSpace(m)))
+			}
+		}
+		list = list.merge(credit)
+	}
+	return list
+}
+
+// Merge a credit into the list
+func (l CreditList) merge(credit AwardCredit) CreditList {
+	for i, c := range l {
+		if c.Award != credit.Award {
+			continue
+		}
+	OUTER:
+		for _, m := range credit.Media {
+			for _, n := range c.Media {
+				if m == n {
+					continue OUTER
+				}
+			}
+			l[i].Media = append(l[i].Media, m)
+		}
+		return l
+	}
+	return append(l, credit)
+}
+
+// Check whether the award is in the list
+func (l CreditList) Has(award string) bool {
+	_, ok := l.Get(award)
+	return ok
+}
+
+// Get the credit of the award
+func (l CreditList) Get(award string) (AwardCredit, bool) {
+	award = strings.ToUpper(award)
+	for _, c := range l {
+		if c.Award == award {
+			return c, true
+		}
+	}
+	return AwardCredit{}, false
+}
+
+// Format the list in the ADIF credit list form
+func (l CreditList) String() string {
+	items := make([]string, 0, len(l))
+	for _, c := range l {
+		if len(c.Media) > 0 {
+			items = append(items, c.Award+":"+strings.Join(c.Media, "&"))
+		} else {
+			items = append(items, c.Award)
+		}
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+// Get the granted credits of the record,
+// merged from CREDIT_GRANTED and APP_LoTW_CREDIT_GRANTED
+func GrantedCredits(record ADIFRecord) CreditList {
+	list := make(CreditList, 0, 4)
+	for _, field := range []string{"credit_granted", "app_lotw_credit_granted"} {
+		if v, err := record.GetValue(field); err == nil {
+			for _, c := range ParseCreditList(v) {
+				list = list.merge(c)
+			}
+		}
+	}
+	return list
+}
+
+// Get the submitted credits of the record (CREDIT_SUBMITTED)
+func SubmittedCredits(record ADIFRecord) CreditList {
+	v, err := record.GetValue("credit_submitted")
+	if err != nil {
+		return CreditList{}
+	}
+	return ParseCreditList(v)
+}
+
+// Check whether the award credit is granted for the record
+func IsCreditGranted(record ADIFRecord, award string) bool {
+	return GrantedCredits(record).Has(award)
+}
+
+// Get the DXCC entity status of the record in the LoTW QSL detail
+// (APP_LoTW_DXCC_ENTITY_STATUS, e.g. "Current" or "Deleted")
+func LOTWEntityStatus(record ADIFRecord) string {
+	v, _ := record.GetValue("app_lotw_dxcc_entity_status")
+	return strings.TrimSpace(v)
+}
+
+// Check whether the DXCC entity of the record is deleted
+func IsDeletedEntity(record ADIFRecord) bool {
+	return strings.EqualFold(LOTWEntityStatus(record), "Deleted")
+}
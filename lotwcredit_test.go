@@ -0,0 +1,79 @@
+package adifparser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseCreditList(t *testing.T) {
+	list := ParseCreditList("dxcc:lotw&card, DXCC_BAND:LOTW,WAS,DXCC:CARD")
+	if len(list) != 3 {
+		t.Fatalf("Expected 3 credits, got %v", list)
+	}
+	if c, ok := list.Get("dxcc"); !ok || strings.Join(c.Media, "&") != "LOTW&CARD" {
+		t.Fatalf("Unexpected DXCC credit %v", c)
+	}
+	if !list.Has(CreditDXCCBand) || !list.Has("WAS") || list.Has(CreditDXCCMode) {
+		t.Fatalf("Unexpected credits %v", list)
+	}
+	if list.String() != "DXCC:LOTW&CARD,DXCC_BAND:LOTW,WAS" {
+		t.Fatalf("Unexpected string %s", list.String())
+	}
+	if len(ParseCreditList("")) != 0 {
+		t.Fatal("Expected an empty list")
+	}
+}
+
+func TestGrantedCredits(t *testing.T) {
+	record := NewADIFRecord()
+	record.SetValue("credit_submitted", "DXCC:LOTW,DXCC_BAND:LOTW")
+	record.SetValue("credit_granted", "DXCC:CARD")
+	record.SetValue("app_lotw_credit_granted", "DXCC:LOTW,DXCC_MODE:LOTW")
+	record.SetValue("APP_LoTW_DXCC_ENTITY_STATUS", "Deleted")
+
+	granted := GrantedCredits(record)
+	if granted.String() != "DXCC:CARD&LOTW,DXCC_MODE:LOTW" {
+		t.Fatalf("Unexpected granted credits %s", granted)
+	}
+	if !IsCreditGranted(record, CreditDXCC) || IsCreditGranted(record, CreditDXCCBand) {
+		t.Fatal("Unexpected credit status")
+	}
+	if !SubmittedCredits(record).Has(CreditDXCCBand) {
+		t.Fatal("Expected DXCC_BAND submitted")
+	}
+	if !IsDeletedEntity(record) {
+		t.Fatal("Expected deleted entity")
+	}
+}
+
+func TestLOTWDXCCCreditClient(t *testing.T) {
+	report := "<eoh>\n<CALL:5>PY2RU<BAND:3>15M<MODE:4>JT65<QSO_DATE:8>20150524" +
+		"<DXCC:3>108<CREDIT_GRANTED:9>DXCC:LOTW<eor>\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("ac_acct") != "12345" || q.Get("login") != "u" || q.Has("qso_query") {
+			t.Errorf("Unexpected query %s", r.URL.RawQuery)
+		}
+		w.Write([]byte(report))
+	}))
+	defer server.Close()
+
+	c := NewLOTWDXCCCreditClient("u", "p", "12345", WithLOTWBaseURL(server.URL))
+	defer c.Close()
+	reader := NewADIFReader(c)
+	count := 0
+	for record, err := range reader.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsCreditGranted(record, CreditDXCC) {
+			t.Fatal("Expected DXCC credit")
+		}
+		count++
+	}
+	if count != 1 {
+		t.Fatalf("Expected 1 record, got %d", count)
+	}
+}
@@ -1,10 +1,10 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"github.com/jj1bdx/adifparser"
-	"io"
 	"os"
 	"time"
 )
@@ -12,6 +12,20 @@ import (
 func main() {
 	var username = flag.String("username", "", "LOTW Username")
 	var password = flag.String("password", "", "LOTW Password")
+	var qslonly = flag.Bool("qslonly", false, "Download QSLs only.")
+	var qslsince = flag.String("qslsince", "", "QSLs received since (YYYY-MM-DD [HH:MM:SS]).")
+	var qsorxsince = flag.String("qsorxsince", "", "QSOs received since (YYYY-MM-DD [HH:MM:SS]).")
+	var owncall = flag.String("owncall", "", "Own station callsign.")
+	var callsign = flag.String("callsign", "", "Worked station callsign.")
+	var mode = flag.String("mode", "", "Mode.")
+	var band = flag.String("band", "", "Band.")
+	var dxcc = flag.String("dxcc", "", "DXCC entity number.")
+	var startdate = flag.String("startdate", "", "QSO start date (YYYY-MM-DD).")
+	var enddate = flag.String("enddate", "", "QSO end date (YYYY-MM-DD).")
+	var incremental = flag.Bool("incremental", false, "Download only the changes since the last run.")
+	var statefile = flag.String("statefile", "lotwdump.state", "State file for incremental downloads.")
+	var timeout = flag.Duration("timeout", 0, "Timeout of each download attempt (e.g. 10m).")
+	var retries = flag.Int("retries", 5, "Maximum number of retries for transient errors.")
 
 	flag.Parse()
 
@@ -20,22 +34,53 @@ func main() {
 		return
 	}
 
-	client := adifparser.NewLOTWClient(*username, *password)
-	reader := adifparser.NewADIFReader(client)
+	options := adifparser.DefaultLOTWQueryOptions()
+	options.QSLOnly = *qslonly
+	options.QSLSince = *qslsince
+	options.QSORxSince = *qsorxsince
+	options.OwnCall = *owncall
+	options.Callsign = *callsign
+	options.Mode = *mode
+	options.Band = *band
+	options.DXCC = *dxcc
+	options.StartDate = *startdate
+	options.EndDate = *enddate
+
+	var state *adifparser.LOTWSyncState
+	if *incremental {
+		var err error
+		state, err = adifparser.LoadLOTWSyncState(*statefile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", *statefile, err)
+			return
+		}
+		state.ApplyOptions(&options)
+	}
+
+	policy := adifparser.DefaultLOTWRetryPolicy()
+	policy.MaxRetries = *retries
+	reader := adifparser.NewLOTWRetryReader(context.Background(),
+		*username, *password, options, policy,
+		adifparser.WithLOTWTimeout(*timeout))
 	writer := adifparser.NewADIFWriter(os.Stdout)
 	defer writer.Flush()
-	defer client.Close()
+	defer reader.Close()
 
 	t := time.Now().Format("2006/01/02 15:04:05")
 	writer.SetComment(fmt.Sprintf("Downloaded from LOTW at %s.", t))
 
-	for record, err := reader.ReadRecord(); record != nil; record, err = reader.ReadRecord() {
+	for record, err := range reader.All() {
 		if err != nil {
-			if err != io.EOF {
-				fmt.Fprintf(os.Stderr, "%v\n", err)
-			}
+			fmt.Fprintf(os.Stderr, "%v\n", err)
 			return
 		}
 		writer.WriteRecord(record)
 	}
+
+	// Save the state only after the whole report is read
+	if state != nil && state.Update(reader) {
+		if err := state.Save(*statefile); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", *statefile, err)
+		}
+	}
 }
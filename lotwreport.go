@@ -0,0 +1,124 @@
+package adifparser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+)
+
+// Errors
+var ErrLOTWRecordCount = errors.New("LoTW record count mismatch")
+
+// LoTW report header metadata
+type LOTWReportInfo struct {
+	// PROGRAMID
+	ProgramID string
+	// Generation time in the report preamble ("Generated at")
+	GeneratedAt string
+	// Login name in the report preamble ("for")
+	User string
+	// Query echo in the report preamble
+	// (e.g. "QSL ONLY": "YES", "QSL RX SINCE": "2021-01-07 00:00:00")
+	Query map[string]string
+	// APP_LoTW_LASTQSL
+	LastQSL string
+	// APP_LoTW_LASTQSORX
+	LastQSORx string
+	// APP_LoTW_NUMREC (-1 if not given)
+	NumRec int
+	// Number of the records read
+	RecordCount int
+	// Whether APP_LoTW_EOF has been seen
+	SawEOF bool
+}
+
+// Parse the LoTW report header
+func parseLOTWReportInfo(r *baseADIFReader) *LOTWReportInfo {
+	info := &LOTWReportInfo{}
+	info.Query = make(map[string]string)
+	info.NumRec = -1
+	info.ProgramID, _ = r.HeaderValue("programid")
+	info.LastQSL, _ = r.HeaderValue("app_lotw_lastqsl")
+	info.LastQSORx, _ = r.HeaderValue("app_lotw_lastqsorx")
+	if v, err := r.HeaderValue("app_lotw_numrec"); err == nil {
+		if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+			info.NumRec = n
+		}
+	}
+
+	inQuery := false
+	for _, line := range strings.Split(r.HeaderComment(), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			inQuery = false
+		case strings.HasPrefix(line, "Generated at "):
+			info.GeneratedAt = strings.TrimPrefix(line, "Generated at ")
+		case strings.HasPrefix(line, "for "):
+			info.User = strings.TrimPrefix(line, "for ")
+		case line == "Query:":
+			inQuery = true
+		case inQuery:
+			if k, v, ok := strings.Cut(line, ":"); ok {
+				info.Query[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+		}
+	}
+	return info
+}
+
+// Verify the number of the records and the end marker
+func (info *LOTWReportInfo) Verify() error {
+	if info.NumRec >= 0 && info.NumRec != info.RecordCount {
+		return fmt.Errorf("%w: APP_LoTW_NUMREC %d, read %d",
+			ErrLOTWRecordCount, info.NumRec, info.RecordCount)
+	}
+	if !info.SawEOF {
+		return fmt.Errorf("%w: no <APP_LoTW_EOF>", ErrLOTWTruncated)
+	}
+	return nil
+}
+
+// ADIFReader for LoTW reports
+// At the end of the report, the record count and the end marker
+// are verified, and a mismatch is returned instead of io.EOF
+type lotwReportReader struct {
+	*baseADIFReader
+	// Report metadata (nil until the header is read)
+	info *LOTWReportInfo
+}
+
+// Create a new LoTW report reader
+func NewLOTWReportReader(r io.Reader) *lotwReportReader {
+	reader := &lotwReportReader{}
+	reader.baseADIFReader = NewADIFReader(r)
+	return reader
+}
+
+func (ardr *lotwReportReader) ReadRecord() (ADIFRecord, error) {
+	record, err := ardr.baseADIFReader.ReadRecord()
+	if err == io.EOF {
+		if verr := ardr.Info().Verify(); verr != nil {
+			return nil, verr
+		}
+	}
+	return record, err
+}
+
+func (ardr *lotwReportReader) All() iter.Seq2[ADIFRecord, error] {
+	return AllRecords(ardr)
+}
+
+// Get the report metadata
+// The record count and the end marker are updated as the records are read
+func (ardr *lotwReportReader) Info() *LOTWReportInfo {
+	if ardr.info == nil {
+		ardr.info = parseLOTWReportInfo(ardr.baseADIFReader)
+	}
+	ardr.info.RecordCount = ardr.RecordCount()
+	ardr.info.SawEOF = ardr.lotwEOF
+	return ardr.info
+}
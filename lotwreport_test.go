@@ -0,0 +1,75 @@
+package adifparser
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func readLOTWReport(t *testing.T, filename string) (*lotwReportReader, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	reader := NewLOTWReportReader(f)
+	for _, err := range reader.All() {
+		if err != nil {
+			return reader, err
+		}
+	}
+	return reader, nil
+}
+
+func TestLOTWReportInfo(t *testing.T) {
+	reader, err := readLOTWReport(t, "testdata/lotw_new.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := reader.Info()
+	if info.ProgramID != "LoTW" || info.LastQSL != "2021-01-07 03:42:14" ||
+		info.LastQSORx != "" || info.NumRec != 4 || info.RecordCount != 4 ||
+		!info.SawEOF {
+		t.Fatalf("Unexpected info %+v", info)
+	}
+	if info.GeneratedAt != "2021-01-07 16:50:05" || info.User != "k0swe" {
+		t.Fatalf("Unexpected preamble %+v", info)
+	}
+	if info.Query["QSL ONLY"] != "YES" ||
+		info.Query["QSL RX SINCE"] != "2021-01-07 00:00:00 (user supplied value)" {
+		t.Fatalf("Unexpected query %v", info.Query)
+	}
+}
+
+func TestLOTWReportVerify(t *testing.T) {
+	expected := map[string]error{
+		"lotw.adi":              nil,
+		"lotw_empty.adi":        nil,
+		"lotw_eof.adi":          nil,
+		"lotw_new.adi":          nil,
+		"lotw_empty_no_eof.adi": ErrLOTWTruncated,
+	}
+	for file, exp := range expected {
+		_, err := readLOTWReport(t, "testdata/"+file)
+		if !errors.Is(err, exp) || (err == nil) != (exp == nil) {
+			t.Fatalf("%s: expected %v, got %v", file, exp, err)
+		}
+	}
+}
+
+func TestLOTWReportRecordCount(t *testing.T) {
+	data, err := os.ReadFile("testdata/lotw_eof.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := strings.Replace(string(data), "<APP_LoTW_NUMREC:1>1", "<APP_LoTW_NUMREC:1>2", 1)
+	reader := NewLOTWReportReader(strings.NewReader(report))
+	if _, err := reader.ReadRecord(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reader.ReadRecord(); !errors.Is(err, ErrLOTWRecordCount) {
+		t.Fatalf("Expected %v, got %v", ErrLOTWRecordCount, err)
+	}
+}
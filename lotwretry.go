@@ -0,0 +1,272 @@
+package adifparser
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"iter"
+	"maps"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Retry policy for LoTW downloads
+type LOTWRetryPolicy struct {
+	// Maximum number of consecutive retries without progress
+	MaxRetries int
+	// Wait before the first retry, doubled for each retry
+	InitialBackoff time.Duration
+	// Upper limit of the wait
+	MaxBackoff time.Duration
+}
+
+// Default retry policy
+func DefaultLOTWRetryPolicy() LOTWRetryPolicy {
+	return LOTWRetryPolicy{
+		MaxRetries:     5,
+		InitialBackoff: 2 * time.Second,
+		MaxBackoff:     2 * time.Minute,
+	}
+}
+
+// Wait before the retry (0-origin)
+func (p LOTWRetryPolicy) backoff(retry int) time.Duration {
+	wait := p.InitialBackoff
+	for i := 0; i < retry && wait < p.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	return wait
+}
+
+// Check whether the LoTW download error is transient
+// Only the server errors (5xx), the timeouts, the reset or refused
+// connections and the truncated reports are retried; the certificate
+// errors, the unsupported URL schemes and the unknown hosts are not
+func IsLOTWRetriable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrLOTWTruncated) {
+		return true
+	}
+	var statusErr *LOTWStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500
+	}
+	if errors.Is(err, ErrLOTWAuth) || errors.Is(err, ErrLOTWHTML) {
+		return false
+	}
+	var verifyErr *tls.CertificateVerificationError
+	var unknownAuthErr x509.UnknownAuthorityError
+	var invalidErr x509.CertificateInvalidError
+	var hostnameErr x509.HostnameError
+	if errors.As(err, &verifyErr) || errors.As(err, &unknownAuthErr) ||
+		errors.As(err, &invalidErr) || errors.As(err, &hostnameErr) {
+		return false
+	}
+	// net/http has no error type for it
+	if strings.Contains(err.Error(), "unsupported protocol scheme") {
+		return false
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// ADIFReader downloading a LoTW report with retries
+//
+// Transient errors (see IsLOTWRetriable) are retried with
+// exponential backoff. LoTW returns the records in the order received,
+// so a retried download resumes from the latest APP_LoTW_RXQSO received
+// by qso_qsorxsince (APP_LoTW_RXQSL by qso_qslsince for a QSL report);
+// the records of the overlap already received are skipped by their
+// fingerprints. The whole report is queried again if no receive time
+// has been received.
+type lotwRetryReader struct {
+	// Request parameters
+	ctx        context.Context
+	username   string
+	password   string
+	options    LOTWQueryOptions
+	policy     LOTWRetryPolicy
+	clientOpts []LOTWClientOption
+	// Current download
+	client *lotwClientImpl
+	reader *baseADIFReader
+	// Header of the first download
+	header *baseADIFReader
+	// Counts of the fingerprints of the received records
+	seen map[string]int
+	// Latest receive time of the received records
+	lastRx string
+	// Counts of the fingerprints of the records received at lastRx
+	seenAtRx map[string]int
+	// Counts of the fingerprints to skip in the current download
+	skip map[string]int
+	// Consecutive retries without progress
+	retries int
+	// Number of downloads
+	attempts int
+	// Record count
+	records int
+}
+
+// Create a new LoTW report reader with retries
+func NewLOTWRetryReader(ctx context.Context, username, password string,
+	options LOTWQueryOptions, policy LOTWRetryPolicy,
+	opts ...LOTWClientOption) *lotwRetryReader {
+	reader := &lotwRetryReader{}
+	reader.ctx = ctx
+	reader.username = username
+	reader.password = password
+	reader.options = options
+	reader.policy = policy
+	reader.clientOpts = append(append([]LOTWClientOption(nil), opts...), WithLOTWContext(ctx))
+	reader.seen = make(map[string]int)
+	reader.seenAtRx = make(map[string]int)
+	return reader
+}
+
+// Field of the receive time of the report
+func (ardr *lotwRetryReader) rxField() string {
+	if ardr.options.QSLOnly {
+		return "app_lotw_rxqsl"
+	}
+	return "app_lotw_rxqso"
+}
+
+// Start a download from the latest receive time
+func (ardr *lotwRetryReader) start() {
+	options := ardr.options
+	if options.QSLOnly {
+		if ardr.lastRx > options.QSLSince {
+			options.QSLSince = ardr.lastRx
+		}
+	} else if ardr.lastRx > options.QSORxSince {
+		options.QSORxSince = ardr.lastRx
+	}
+	ardr.client = NewLOTWClientWithOptions(
+		ardr.username, ardr.password, options, ardr.clientOpts...)
+	ardr.reader = NewADIFReader(ardr.client)
+	ardr.attempts++
+}
+
+func (ardr *lotwRetryReader) ReadRecord() (ADIFRecord, error) {
+	for {
+		if ardr.reader == nil {
+			ardr.start()
+		}
+		record, err := ardr.reader.ReadRecord()
+		if err == nil || err == io.EOF {
+			if ardr.header == nil {
+				ardr.header = ardr.reader
+			}
+		}
+		if err == io.EOF {
+			if verr := ardr.Info().Verify(); verr != nil {
+				return nil, verr
+			}
+			return nil, err
+		}
+		if err == nil {
+			fp := record.Fingerprint()
+			if ardr.skip[fp] > 0 {
+				ardr.skip[fp]--
+				continue
+			}
+			ardr.seen[fp]++
+			// "YYYY-MM-DD HH:MM:SS" in the order of the time
+			rx := RecordValue(record, ardr.rxField())
+			if rx > ardr.lastRx {
+				ardr.lastRx = rx
+				clear(ardr.seenAtRx)
+			}
+			if rx != "" && rx == ardr.lastRx {
+				ardr.seenAtRx[fp]++
+			}
+			ardr.retries = 0
+			ardr.records++
+			return record, nil
+		}
+
+		ardr.Close()
+		if ardr.ctx.Err() != nil || !IsLOTWRetriable(err) ||
+			ardr.retries >= ardr.policy.MaxRetries {
+			return nil, err
+		}
+		adiflog.Printf("LOTW download failed, retrying: %v", err)
+		timer := time.NewTimer(ardr.policy.backoff(ardr.retries))
+		select {
+		case <-timer.C:
+		case <-ardr.ctx.Done():
+			timer.Stop()
+			return nil, ardr.ctx.Err()
+		}
+		ardr.retries++
+		// Only the records at the latest receive time are repeated
+		// in a resumed download
+		if ardr.lastRx != "" {
+			ardr.skip = maps.Clone(ardr.seenAtRx)
+		} else {
+			ardr.skip = maps.Clone(ardr.seen)
+		}
+	}
+}
+
+func (ardr *lotwRetryReader) RecordCount() int {
+	return ardr.records
+}
+
+// Number of downloads including the retries
+func (ardr *lotwRetryReader) Attempts() int {
+	return ardr.attempts
+}
+
+// Get a header field value of the first download
+func (ardr *lotwRetryReader) HeaderValue(name string) (string, error) {
+	if ardr.header == nil {
+		return "", ErrNoSuchField
+	}
+	return ardr.header.HeaderValue(name)
+}
+
+// Get the report metadata of the first download
+// The record count and the end marker reflect all the downloads
+func (ardr *lotwRetryReader) Info() *LOTWReportInfo {
+	if ardr.header == nil {
+		return &LOTWReportInfo{Query: map[string]string{}, NumRec: -1}
+	}
+	info := parseLOTWReportInfo(ardr.header)
+	info.RecordCount = ardr.records
+	info.SawEOF = ardr.reader != nil && ardr.reader.lotwEOF
+	return info
+}
+
+func (ardr *lotwRetryReader) All() iter.Seq2[ADIFRecord, error] {
+	return AllRecords(ardr)
+}
+
+// Close the current download
+func (ardr *lotwRetryReader) Close() error {
+	if ardr.client == nil {
+		return nil
+	}
+	err := ardr.client.Close()
+	ardr.client = nil
+	ardr.reader = nil
+	return err
+}
@@ -0,0 +1,265 @@
+package adifparser
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+var testRetryPolicy = LOTWRetryPolicy{
+	MaxRetries:     3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     4 * time.Millisecond,
+}
+
+// Write a part of the response and drop the connection
+func writeTruncated(w http.ResponseWriter, data []byte, n int) {
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Write(data[:n])
+}
+
+func TestLOTWRetryPolicyBackoff(t *testing.T) {
+	p := LOTWRetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	for i, expected := range []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if p.backoff(i) != expected {
+			t.Fatalf("Retry %d: expected %v, got %v", i, expected, p.backoff(i))
+		}
+	}
+}
+
+func TestIsLOTWRetriable(t *testing.T) {
+	urlError := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://lotw.arrl.org/", Err: err}
+	}
+	opError := func(err error) error {
+		return urlError(&net.OpError{Op: "dial", Net: "tcp", Err: err})
+	}
+	_, schemeErr := http.Get("ftp://lotw.arrl.org/")
+	for _, tc := range []struct {
+		name      string
+		err       error
+		retriable bool
+	}{
+		{"truncated", fmt.Errorf("%w: 10 of 20 records", ErrLOTWTruncated), true},
+		{"unexpected EOF", urlError(io.ErrUnexpectedEOF), true},
+		{"server error", &LOTWStatusError{StatusCode: 503}, true},
+		{"client error", &LOTWStatusError{StatusCode: 404}, false},
+		{"auth", ErrLOTWAuth, false},
+		{"html", ErrLOTWHTML, false},
+		{"timeout", urlError(os.ErrDeadlineExceeded), true},
+		{"DNS timeout", opError(&net.DNSError{Err: "timeout", IsTimeout: true}), true},
+		{"connection reset", opError(os.NewSyscallError("read", syscall.ECONNRESET)), true},
+		{"connection refused", opError(os.NewSyscallError("connect", syscall.ECONNREFUSED)), true},
+		{"unreachable", opError(os.NewSyscallError("connect", syscall.EHOSTUNREACH)), false},
+		{"certificate verification", urlError(&tls.CertificateVerificationError{
+			Err: x509.UnknownAuthorityError{}}), false},
+		{"unknown authority", urlError(x509.UnknownAuthorityError{}), false},
+		{"invalid certificate", urlError(x509.CertificateInvalidError{Reason: x509.Expired}), false},
+		{"hostname", urlError(x509.HostnameError{Host: "lotw.arrl.org"}), false},
+		{"unsupported scheme", schemeErr, false},
+		{"no such host", opError(&net.DNSError{Err: "no such host", IsNotFound: true}), false},
+		{"other", errors.New("other"), false},
+		{"nil", nil, false},
+	} {
+		if IsLOTWRetriable(tc.err) != tc.retriable {
+			t.Errorf("%s: expected %v for %v", tc.name, tc.retriable, tc.err)
+		}
+	}
+}
+
+func TestLOTWRetryReaderFullReport(t *testing.T) {
+	report, err := os.ReadFile("testdata/lotw.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch requests {
+		case 1:
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		case 2:
+			writeTruncated(w, report, len(report)/2)
+		default:
+			// The original query is repeated
+			if r.URL.Query().Has("qso_startdate") {
+				t.Errorf("Unexpected query %s", r.URL.RawQuery)
+			}
+			w.Write(report)
+		}
+	}))
+	defer server.Close()
+
+	reader := NewLOTWRetryReader(context.Background(), "u", "p",
+		DefaultLOTWQueryOptions(), testRetryPolicy, WithLOTWBaseURL(server.URL))
+	defer reader.Close()
+	seen := make(map[string]bool)
+	for record, err := range reader.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[record.Fingerprint()] = true
+	}
+	if reader.RecordCount() != 250 || len(seen) != 250 {
+		t.Fatalf("Expected 250 records, got %d (%d unique)", reader.RecordCount(), len(seen))
+	}
+	if reader.Attempts() != 3 || requests != 3 {
+		t.Fatalf("Expected 3 requests, got %d (%d)", reader.Attempts(), requests)
+	}
+	if v, err := reader.HeaderValue("app_lotw_numrec"); err != nil || v != "250" {
+		t.Fatalf("Unexpected header value %s (%v)", v, err)
+	}
+}
+
+// QSOs in the receive order: the QSO dates of W5AW to W9AW are
+// earlier than the one of W4AW
+func makeReceiveOrderRecords() []string {
+	records := make([]string, 0, 10)
+	for i := 0; i <= 9; i++ {
+		day := i + 10
+		if i >= 5 {
+			day = i - 4
+		}
+		records = append(records, fmt.Sprintf("<CALL:4>W%dAW<BAND:3>20M<MODE:3>FT8"+
+			"<QSO_DATE:8>201501%02d<TIME_ON:6>12%02d00"+
+			"<APP_LoTW_RXQSO:19>2015-02-01 00:%02d:00<eor>\n", i, day, i, i/2))
+	}
+	return records
+}
+
+// Report of the records received at or after since
+func makeReceiveOrderReport(records []string, since string) string {
+	var body strings.Builder
+	n := 0
+	for _, record := range records {
+		i := strings.Index(record, "<APP_LoTW_RXQSO:19>") + 19
+		if record[i:i+19] >= since {
+			body.WriteString(record)
+			n++
+		}
+	}
+	return fmt.Sprintf("ARRL Logbook of the World Status Report\n"+
+		"<PROGRAMID:4>LoTW\n<APP_LoTW_NUMREC:%d>%d\n<eoh>\n%s<APP_LoTW_EOF>\n",
+		len(strconv.Itoa(n)), n, body.String())
+}
+
+// Read the calls of the report dropped after n records
+// and the qso_qsorxsince of the second request
+func readResumedCalls(t *testing.T, records []string, n int) ([]string, string) {
+	requests := 0
+	since := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			full := makeReceiveOrderReport(records, "")
+			drop := strings.Index(full, records[0]) + len(strings.Join(records[:n], ""))
+			writeTruncated(w, []byte(full), drop)
+			return
+		}
+		if r.URL.Query().Has("qso_startdate") || r.URL.Query().Has("qso_starttime") {
+			t.Errorf("Unexpected query %s", r.URL.RawQuery)
+		}
+		since = r.URL.Query().Get("qso_qsorxsince")
+		w.Write([]byte(makeReceiveOrderReport(records, since)))
+	}))
+	defer server.Close()
+
+	reader := NewLOTWRetryReader(context.Background(), "u", "p",
+		DefaultLOTWQueryOptions(), testRetryPolicy, WithLOTWBaseURL(server.URL))
+	defer reader.Close()
+	calls := []string{}
+	for record, err := range reader.All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		call, _ := record.GetValue("call")
+		calls = append(calls, call)
+	}
+	if requests != 2 {
+		t.Fatalf("Expected 2 requests, got %d", requests)
+	}
+	return calls, since
+}
+
+func TestLOTWRetryReaderResume(t *testing.T) {
+	// Dropped after W4AW, received at 00:02:00 with W5AW
+	calls, since := readResumedCalls(t, makeReceiveOrderRecords(), 5)
+	// Resumed from the receive time, not from the QSO date of W4AW,
+	// which would lose W5AW to W9AW
+	if since != "2015-02-01 00:02:00" {
+		t.Fatalf("Unexpected qso_qsorxsince %q", since)
+	}
+	if len(calls) != 10 || calls[4] != "W4AW" || calls[5] != "W5AW" || calls[9] != "W9AW" {
+		t.Fatalf("Unexpected records %v", calls)
+	}
+}
+
+func TestLOTWRetryReaderResumeDuplicates(t *testing.T) {
+	// Two identical QSOs received at the same time, dropped between them
+	records := makeReceiveOrderRecords()
+	records[5] = records[4]
+	calls, since := readResumedCalls(t, records, 5)
+	if since != "2015-02-01 00:02:00" {
+		t.Fatalf("Unexpected qso_qsorxsince %q", since)
+	}
+	if len(calls) != 10 || calls[4] != "W4AW" || calls[5] != "W4AW" || calls[6] != "W6AW" {
+		t.Fatalf("Unexpected records %v", calls)
+	}
+}
+
+func TestLOTWRetryReaderFatal(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html>Username/password incorrect</html>"))
+	}))
+	defer server.Close()
+
+	reader := NewLOTWRetryReader(context.Background(), "u", "p",
+		DefaultLOTWQueryOptions(), testRetryPolicy, WithLOTWBaseURL(server.URL))
+	defer reader.Close()
+	if _, err := reader.ReadRecord(); !errors.Is(err, ErrLOTWAuth) {
+		t.Fatalf("Expected %v, got %v", ErrLOTWAuth, err)
+	}
+	if requests != 2 {
+		t.Fatalf("Expected 2 requests, got %d", requests)
+	}
+}
+
+func TestLOTWRetryReaderGiveUp(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	reader := NewLOTWRetryReader(context.Background(), "u", "p",
+		DefaultLOTWQueryOptions(), testRetryPolicy, WithLOTWBaseURL(server.URL))
+	defer reader.Close()
+	if _, err := reader.ReadRecord(); !errors.Is(err, ErrLOTWHTTPStatus) {
+		t.Fatalf("Expected %v, got %v", ErrLOTWHTTPStatus, err)
+	}
+	if requests != testRetryPolicy.MaxRetries+1 {
+		t.Fatalf("Expected %d requests, got %d", testRetryPolicy.MaxRetries+1, requests)
+	}
+}
@@ -0,0 +1,81 @@
+package adifparser
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// Persisted state for incremental LoTW downloads
+// The values are taken from the LoTW report header
+// and passed as qso_qslsince/qso_qsorxsince on the next download
+type LOTWSyncState struct {
+	// APP_LoTW_LASTQSL of the last QSL-only report
+	LastQSL string `json:"app_lotw_lastqsl,omitempty"`
+	// APP_LoTW_LASTQSORX of the last all-QSO report
+	LastQSORx string `json:"app_lotw_lastqsorx,omitempty"`
+}
+
+// Interface for the readers providing header values
+type ADIFHeaderReader interface {
+	HeaderValue(string) (string, error)
+}
+
+// Load the sync state from the file
+// A missing file results in an empty state
+func LoadLOTWSyncState(path string) (*LOTWSyncState, error) {
+	state := &LOTWSyncState{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return state, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Save the sync state to the file
+// The file is replaced atomically
+func (s *LOTWSyncState) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// Set the "since" query option from the state
+// qso_qslsince is used for QSL-only reports,
+// and qso_qsorxsince for all-QSO reports
+func (s *LOTWSyncState) ApplyOptions(options *LOTWQueryOptions) {
+	if options.QSLOnly {
+		if s.LastQSL != "" {
+			options.QSLSince = s.LastQSL
+		}
+	} else {
+		if s.LastQSORx != "" {
+			options.QSORxSince = s.LastQSORx
+		}
+	}
+}
+
+// Update the state from the report header
+// Missing values (e.g. of an empty report) keep the previous state
+// Returns true if the state is changed
+func (s *LOTWSyncState) Update(r ADIFHeaderReader) bool {
+	changed := false
+	if v, err := r.HeaderValue("app_lotw_lastqsl"); err == nil && v != s.LastQSL {
+		s.LastQSL = v
+		changed = true
+	}
+	if v, err := r.HeaderValue("app_lotw_lastqsorx"); err == nil && v != s.LastQSORx {
+		s.LastQSORx = v
+		changed = true
+	}
+	return changed
+}
@@ -0,0 +1,60 @@
+package adifparser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLOTWSyncState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lotw.state")
+	state, err := LoadLOTWSyncState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.LastQSL != "" || state.LastQSORx != "" {
+		t.Fatalf("Expected empty state, got %v", state)
+	}
+
+	for _, file := range []string{"lotw_new.adi", "lotw_eof.adi", "lotw_empty.adi"} {
+		f, err := os.Open(filepath.Join("testdata", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		state.Update(NewADIFReader(f))
+		f.Close()
+	}
+	if state.LastQSL != "2021-01-07 03:42:14" {
+		t.Fatalf("Unexpected LastQSL %s", state.LastQSL)
+	}
+	if state.LastQSORx != "2020-11-27 05:15:17" {
+		t.Fatalf("Unexpected LastQSORx %s", state.LastQSORx)
+	}
+	if err := state.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadLOTWSyncState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *loaded != *state {
+		t.Fatalf("Expected %v, got %v", state, loaded)
+	}
+
+	options := DefaultLOTWQueryOptions()
+	loaded.ApplyOptions(&options)
+	if options.QSORxSince != "2020-11-27 05:15:17" || options.QSLSince != "" {
+		t.Fatalf("Unexpected options %v", options)
+	}
+	options = DefaultLOTWQueryOptions()
+	options.QSLOnly = true
+	loaded.ApplyOptions(&options)
+	if options.QSLSince != "2021-01-07 03:42:14" || options.QSORxSince != "" {
+		t.Fatalf("Unexpected options %v", options)
+	}
+	params := NewLOTWClientWithOptions("u", "p", options).getParams()
+	if params["qso_qslsince"] != "2021-01-07 03:42:14" {
+		t.Fatalf("Unexpected params %v", params)
+	}
+}
@@ -0,0 +1,193 @@
+package adifparser
+
+import (
+	"strings"
+)
+
+// ADIF versions to be set in the header of converted output
+const (
+	ADIFVersion2 = "2.2.7"
+	ADIFVersion3 = "3.1.4"
+)
+
+type modePair struct {
+	mode    string
+	submode string
+}
+
+// ADIF 2 and non-standard modes represented as
+// MODE and SUBMODE pairs in ADIF 3
+// JT4, JT9 and JT65 are ADIF 3 modes as they are, and their submodes
+// (JT4A, JT9A, JT65A etc.) are kept under them as in the ADIF 3 mode list
+var adif3ModePairs = map[string]modePair{
+	"AMTORFEC": {"TOR", "AMTORFEC"},
+	"ASCI":     {"RTTY", "ASCI"},
+	"CHIP64":   {"CHIP", "CHIP64"},
+	"CHIP128":  {"CHIP", "CHIP128"},
+	"DOMINOF":  {"DOMINO", "DOMINOF"},
+	"FMHELL":   {"HELL", "FMHELL"},
+	"FSK31":    {"PSK", "FSK31"},
+	"FST4":     {"MFSK", "FST4"},
+	"FT4":      {"MFSK", "FT4"},
+	"GTOR":     {"TOR", "GTOR"},
+	"HELL80":   {"HELL", "HELL80"},
+	"HFSK":     {"HELL", "HFSK"},
+	"JS8":      {"MFSK", "JS8"},
+	"JT4A":     {"JT4", "JT4A"},
+	"JT4B":     {"JT4", "JT4B"},
+	"JT4C":     {"JT4", "JT4C"},
+	"JT4D":     {"JT4", "JT4D"},
+	"JT4E":     {"JT4", "JT4E"},
+	"JT4F":     {"JT4", "JT4F"},
+	"JT4G":     {"JT4", "JT4G"},
+	"JT9-1":    {"JT9", "JT9-1"},
+	"JT9-2":    {"JT9", "JT9-2"},
+	"JT9-5":    {"JT9", "JT9-5"},
+	"JT9-10":   {"JT9", "JT9-10"},
+	"JT9-30":   {"JT9", "JT9-30"},
+	"JT9A":     {"JT9", "JT9A"},
+	"JT9B":     {"JT9", "JT9B"},
+	"JT9C":     {"JT9", "JT9C"},
+	"JT9D":     {"JT9", "JT9D"},
+	"JT9E":     {"JT9", "JT9E"},
+	"JT9F":     {"JT9", "JT9F"},
+	"JT9G":     {"JT9", "JT9G"},
+	"JT9H":     {"JT9", "JT9H"},
+	"JT65A":    {"JT65", "JT65A"},
+	"JT65B":    {"JT65", "JT65B"},
+	"JT65B2":   {"JT65", "JT65B2"},
+	"JT65C":    {"JT65", "JT65C"},
+	"JT65C2":   {"JT65", "JT65C2"},
+	"LSB":      {"SSB", "LSB"},
+	"MFSK8":    {"MFSK", "MFSK8"},
+	"MFSK16":   {"MFSK", "MFSK16"},
+	"PAC2":     {"PAC", "PAC2"},
+	"PAC3":     {"PAC", "PAC3"},
+	"PCW":      {"CW", "PCW"},
+	"PSK10":    {"PSK", "PSK10"},
+	"PSK31":    {"PSK", "PSK31"},
+	"PSK63":    {"PSK", "PSK63"},
+	"PSK63F":   {"PSK", "PSK63F"},
+	"PSK125":   {"PSK", "PSK125"},
+	"PSKAM10":  {"PSK", "PSKAM10"},
+	"PSKAM31":  {"PSK", "PSKAM31"},
+	"PSKAM50":  {"PSK", "PSKAM50"},
+	"PSKFEC31": {"PSK", "PSKFEC31"},
+	"PSKHELL":  {"HELL", "PSKHELL"},
+	"Q65":      {"MFSK", "Q65"},
+	"QPSK31":   {"PSK", "QPSK31"},
+	"QPSK63":   {"PSK", "QPSK63"},
+	"QPSK125":  {"PSK", "QPSK125"},
+	"THRBX":    {"THRB", "THRBX"},
+	"USB":      {"SSB", "USB"},
+}
+
+// Modes of the pairs which are not ADIF 2 modes
+// (downgraded to the MODE without SUBMODE)
+var adif3OnlyModes = map[string]bool{
+	// ADIF 2 has no USB/LSB modes, only SSB
+	"USB": true, "LSB": true,
+	"FST4": true, "FT4": true, "JS8": true, "Q65": true,
+	"JT9-1": true, "JT9-2": true, "JT9-5": true, "JT9-10": true, "JT9-30": true,
+	"JT9A": true, "JT9B": true, "JT9C": true, "JT9D": true,
+	"JT9E": true, "JT9F": true, "JT9G": true, "JT9H": true,
+	"JT65B2": true, "JT65C2": true,
+}
+
+// ADIF 3 MODE and SUBMODE pairs to ADIF 2 modes
+var adif2Modes map[modePair]string
+
+func init() {
+	adif2Modes = make(map[modePair]string)
+	for legacy, pair := range adif3ModePairs {
+		if adif3OnlyModes[legacy] {
+			continue
+		}
+		adif2Modes[pair] = legacy
+	}
+}
+
+// Header comment with ADIF_VER for SetComment of an ADIFWriter
+// e.g. "Converted\n<adif_ver:5>3.1.4\n"
+func ADIFVersionComment(comment string, version string) string {
+	if comment != "" {
+		comment += "\n"
+	}
+	return comment + serializeField("adif_ver", version) + "\n"
+}
+
+// Convert the mode of the record to an ADIF 3 MODE and SUBMODE pair
+// Returns true if the record is modified
+func UpgradeMode(record ADIFRecord) bool {
+	mode, err := record.GetValue("mode")
+	if err != nil {
+		return false
+	}
+	pair, ok := adif3ModePairs[strings.ToUpper(strings.TrimSpace(mode))]
+	if !ok {
+		return false
+	}
+	record.SetValue("mode", pair.mode)
+	record.SetValue("submode", pair.submode)
+	return true
+}
+
+// Convert the MODE and SUBMODE pair of the record to an ADIF 2 mode
+// SUBMODE, which does not exist in ADIF 2, is always removed;
+// MODE is left as it is if the pair has no ADIF 2 mode (e.g. MFSK/FT4 to MFSK)
+// Returns true if the record is modified
+func DowngradeMode(record ADIFRecord) bool {
+	submode, err := record.GetValue("submode")
+	if err != nil {
+		return false
+	}
+	record.DeleteField("submode")
+	mode, err := record.GetValue("mode")
+	if err != nil {
+		return true
+	}
+	pair := modePair{
+		strings.ToUpper(strings.TrimSpace(mode)),
+		strings.ToUpper(strings.TrimSpace(submode))}
+	if legacy, ok := adif2Modes[pair]; ok {
+		record.SetValue("mode", legacy)
+	}
+	return true
+}
+
+// ADIFReader wrapper which converts the modes of each record
+type modeConvADIFReader struct {
+	// Underlying reader
+	rdr ADIFReader
+	// Conversion function
+	convert func(ADIFRecord) bool
+}
+
+// Create a new reader converting modes to ADIF 3
+func NewModeUpgradeADIFReader(r ADIFReader) *modeConvADIFReader {
+	reader := &modeConvADIFReader{}
+	reader.rdr = r
+	reader.convert = UpgradeMode
+	return reader
+}
+
+// Create a new reader converting modes to ADIF 2
+func NewModeDowngradeADIFReader(r ADIFReader) *modeConvADIFReader {
+	reader := &modeConvADIFReader{}
+	reader.rdr = r
+	reader.convert = DowngradeMode
+	return reader
+}
+
+func (ardr *modeConvADIFReader) ReadRecord() (ADIFRecord, error) {
+	record, err := ardr.rdr.ReadRecord()
+	if err != nil {
+		return nil, err
+	}
+	ardr.convert(record)
+	return record, nil
+}
+
+func (ardr *modeConvADIFReader) RecordCount() int {
+	return ardr.rdr.RecordCount()
+}
@@ -0,0 +1,92 @@
+package adifparser
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func testModeConv(t *testing.T, convert func(ADIFRecord) bool,
+	mode, submode, expMode, expSubmode string) {
+	record := NewADIFRecord()
+	record.SetValue("mode", mode)
+	if submode != "" {
+		record.SetValue("submode", submode)
+	}
+	convert(record)
+	if v, _ := record.GetValue("mode"); v != expMode {
+		t.Fatalf("%s/%s: expected mode %s, got %s", mode, submode, expMode, v)
+	}
+	if v, _ := record.GetValue("submode"); v != expSubmode {
+		t.Fatalf("%s/%s: expected submode %s, got %s", mode, submode, expSubmode, v)
+	}
+}
+
+func TestUpgradeMode(t *testing.T) {
+	testModeConv(t, UpgradeMode, "PSK31", "", "PSK", "PSK31")
+	testModeConv(t, UpgradeMode, "usb", "", "SSB", "USB")
+	testModeConv(t, UpgradeMode, "JT65A", "", "JT65", "JT65A")
+	testModeConv(t, UpgradeMode, "JT65B2", "", "JT65", "JT65B2")
+	testModeConv(t, UpgradeMode, "JT65", "", "JT65", "")
+	testModeConv(t, UpgradeMode, "jt9", "", "jt9", "")
+	testModeConv(t, UpgradeMode, "JT9A", "", "JT9", "JT9A")
+	testModeConv(t, UpgradeMode, "JT4G", "", "JT4", "JT4G")
+	testModeConv(t, UpgradeMode, "FT8", "", "FT8", "")
+	testModeConv(t, UpgradeMode, "FT4", "", "MFSK", "FT4")
+}
+
+func TestDowngradeMode(t *testing.T) {
+	testModeConv(t, DowngradeMode, "PSK", "PSK31", "PSK31", "")
+	testModeConv(t, DowngradeMode, "SSB", "USB", "SSB", "")
+	testModeConv(t, DowngradeMode, "MFSK", "FT4", "MFSK", "")
+	testModeConv(t, DowngradeMode, "MFSK", "Q65", "MFSK", "")
+	testModeConv(t, DowngradeMode, "JT65", "JT65B", "JT65B", "")
+	testModeConv(t, DowngradeMode, "JT65", "JT65B2", "JT65", "")
+	testModeConv(t, DowngradeMode, "JT9", "JT9A", "JT9", "")
+	testModeConv(t, DowngradeMode, "JT4", "JT4C", "JT4C", "")
+	testModeConv(t, DowngradeMode, "JT65", "", "JT65", "")
+	testModeConv(t, DowngradeMode, "MFSK", "FSQCALL", "MFSK", "")
+}
+
+func TestModeUpgradeADIFReader(t *testing.T) {
+	f, err := os.Open("testdata/xlog.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	writer := NewADIFWriter(&buf)
+	writer.SetComment(ADIFVersionComment("Converted", ADIFVersion3))
+	reader := NewModeUpgradeADIFReader(NewADIFReader(f))
+	for {
+		record, err := reader.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, _ := record.GetValue("mode"); v == "PSK31" {
+			t.Fatal("PSK31 was not converted")
+		}
+		writer.WriteRecord(record)
+	}
+	writer.Flush()
+
+	if !strings.HasPrefix(buf.String(), "Converted\n<adif_ver:5>3.1.4\n<eoh>\n") {
+		t.Fatalf("Unexpected header: %.40q", buf.String())
+	}
+	if !strings.Contains(buf.String(), "<mode:3>PSK<qso_date:8>20150214") {
+		t.Fatal("Converted PSK31 record not found")
+	}
+	out := NewADIFReader(&buf)
+	if _, err := out.ReadRecord(); err != nil {
+		t.Fatal(err)
+	}
+	if out.version != ADIFVersion3 {
+		t.Fatalf("Expected version %s, got %s", ADIFVersion3, out.version)
+	}
+}
@@ -0,0 +1,141 @@
+package adifparser
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const QRZLogbookAPI string = "https://logbook.qrz.com/api"
+
+// QRZ.com Logbook API client
+type qrzClientImpl struct {
+	serviceClient
+	// Logbook API key
+	apiKey string
+	// Current time for the upload date
+	now func() time.Time
+}
+
+// Create a new QRZ.com Logbook client
+func NewQRZClient(apiKey string, opts ...ServiceClientOption) *qrzClientImpl {
+	client := &qrzClientImpl{}
+	client.init(QRZLogbookAPI, opts)
+	client.apiKey = apiKey
+	client.now = time.Now
+	return client
+}
+
+// Send a request and parse the name-value pair response
+func (c *qrzClientImpl) request(ctx context.Context, form url.Values) (url.Values, error) {
+	form.Set("KEY", c.apiKey)
+	status, body, err := c.postForm(ctx, "", form)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%w: %d %s", ErrServiceHTTPStatus, status,
+			strings.TrimSpace(string(body)))
+	}
+	response := parseQRZResponse(strings.TrimSpace(string(body)))
+	if response.Get("RESULT") == "AUTH" {
+		return nil, fmt.Errorf("%w: %s", ErrServiceAuth, response.Get("REASON"))
+	}
+	return response, nil
+}
+
+// Parse a response of &-separated name-value pairs
+// The values are not URL-encoded; the ADIF value is the rest of the response
+// after "ADIF=", which has raw ";" and "&" of the HTML entities
+func parseQRZResponse(body string) url.Values {
+	response := url.Values{}
+	pairs := body
+	if i := strings.Index(body, "ADIF="); i == 0 || (i > 0 && body[i-1] == '&') {
+		pairs = strings.TrimSuffix(body[:i], "&")
+		response.Set("ADIF", body[i+len("ADIF="):])
+	}
+	for _, pair := range strings.Split(pairs, "&") {
+		if pair == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(pair, "=")
+		response.Set(name, value)
+	}
+	return response
+}
+
+// Insert a QSO (ACTION=INSERT)
+// If replace is true, an existing duplicate QSO is overwritten
+// qrzcom_qso_upload_status and qrzcom_qso_upload_date are set
+// for the inserted and duplicate QSOs
+func (c *qrzClientImpl) Insert(ctx context.Context, r ADIFRecord, replace bool) (UploadResult, error) {
+	form := url.Values{
+		"ACTION": {"INSERT"},
+		"ADIF":   {recordADIF(r)},
+	}
+	if replace {
+		form.Set("OPTION", "REPLACE")
+	}
+	response, err := c.request(ctx, form)
+	if err != nil {
+		return UploadResult{}, err
+	}
+	result := UploadResult{Record: r}
+	switch response.Get("RESULT") {
+	case "OK", "REPLACE":
+		result.Status = UploadInserted
+		result.LogID = response.Get("LOGID")
+	default:
+		result.Reason = response.Get("REASON")
+		if strings.Contains(strings.ToLower(result.Reason), "duplicate") {
+			result.Status = UploadDuplicate
+		} else {
+			result.Status = UploadRejected
+		}
+	}
+	setUploadStatus(r, "qrzcom", result.Status, c.now())
+	return result, nil
+}
+
+// Insert all the QSOs of the reader
+// Stops at the first error other than a rejection
+func (c *qrzClientImpl) InsertAll(ctx context.Context, rdr ADIFReader, replace bool) ([]UploadResult, error) {
+	results := make([]UploadResult, 0, 16)
+	for record, err := range AllRecords(rdr) {
+		if err != nil {
+			return results, err
+		}
+		result, err := c.Insert(ctx, record, replace)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// Fetch QSOs (ACTION=FETCH)
+// option is the OPTION value, e.g. "ALL" or "MAX:250,AFTERLOGID:1234"
+func (c *qrzClientImpl) Fetch(ctx context.Context, option string) ([]ADIFRecord, error) {
+	form := url.Values{"ACTION": {"FETCH"}}
+	if option != "" {
+		form.Set("OPTION", option)
+	}
+	response, err := c.request(ctx, form)
+	if err != nil {
+		return nil, err
+	}
+	if response.Get("RESULT") != "OK" {
+		reason := response.Get("REASON")
+		if response.Get("COUNT") == "0" || strings.Contains(strings.ToLower(reason), "no log entries") {
+			return []ADIFRecord{}, nil
+		}
+		return nil, fmt.Errorf("%w: %s", ErrServiceRequest, reason)
+	}
+	// The ADIF value has the tags escaped as HTML entities
+	return parseADIFRecords(html.UnescapeString(response.Get("ADIF")))
+}
@@ -0,0 +1,92 @@
+package adifparser
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newQRZTestServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("KEY") != "k" {
+			w.Write([]byte("RESULT=AUTH&REASON=invalid api key"))
+			return
+		}
+		switch r.FormValue("ACTION") {
+		case "INSERT":
+			record, err := NewADIFReader(strings.NewReader(r.FormValue("ADIF"))).ReadRecord()
+			if err != nil {
+				t.Error(err)
+			}
+			switch call, _ := record.GetValue("call"); call {
+			case "W1AW":
+				w.Write([]byte("RESULT=FAIL&REASON=Unable to add QSO to database: duplicate&COUNT=0"))
+			case "XX0XX":
+				w.Write([]byte("RESULT=FAIL&REASON=invalid call&COUNT=0"))
+			default:
+				w.Write([]byte("RESULT=OK&LOGID=130877825&COUNT=1"))
+			}
+		case "FETCH":
+			if r.FormValue("OPTION") != "MAX:10" {
+				t.Errorf("Unexpected option %s", r.FormValue("OPTION"))
+			}
+			// The ADIF value is HTML-entity-encoded but not URL-encoded
+			w.Write([]byte("RESULT=OK&COUNT=2&ADIF=&lt;call:4&gt;W1AW&lt;band:3&gt;20m" +
+				"&lt;comment:10&gt;73; R&amp;R gl&lt;eor&gt;\n" +
+				"&lt;call:5&gt;KL3MM&lt;band:3&gt;40m&lt;eor&gt;\n"))
+		}
+	}))
+}
+
+func TestQRZInsert(t *testing.T) {
+	server := newQRZTestServer(t)
+	defer server.Close()
+
+	c := NewQRZClient("k", WithServiceBaseURL(server.URL))
+	results, err := c.InsertAll(context.Background(),
+		NewADIFReader(strings.NewReader(testServiceRecords)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []UploadStatus{UploadInserted, UploadDuplicate, UploadRejected}
+	for i, result := range results {
+		if result.Status != expected[i] {
+			t.Fatalf("Result %d: expected %v, got %v", i, expected[i], result.Status)
+		}
+		v, _ := result.Record.GetValue("qrzcom_qso_upload_status")
+		if (result.Status == UploadRejected) != (v == "") {
+			t.Fatalf("Result %d: unexpected upload status %q", i, v)
+		}
+	}
+	if results[0].LogID != "130877825" || results[2].Reason != "invalid call" {
+		t.Fatalf("Unexpected results %+v", results)
+	}
+
+	c = NewQRZClient("bad", WithServiceBaseURL(server.URL))
+	if _, err := c.Insert(context.Background(), results[0].Record, false); !errors.Is(err, ErrServiceAuth) {
+		t.Fatalf("Expected %v, got %v", ErrServiceAuth, err)
+	}
+}
+
+func TestQRZFetch(t *testing.T) {
+	server := newQRZTestServer(t)
+	defer server.Close()
+
+	c := NewQRZClient("k", WithServiceBaseURL(server.URL))
+	records, err := c.Fetch(context.Background(), "MAX:10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if v, _ := records[1].GetValue("call"); v != "KL3MM" {
+		t.Fatalf("Unexpected call %s", v)
+	}
+	if v, _ := records[0].GetValue("comment"); v != "73; R&R gl" {
+		t.Fatalf("Unexpected comment %q", v)
+	}
+}
@@ -0,0 +1,403 @@
+package adifparser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record query expression language
+//
+// Grammar:
+//
+//	expr       = term { "or" term }
+//	term       = factor { "and" factor }
+//	factor     = "not" factor | "(" expr ")" | comparison
+//	comparison = field op value | field "in" "(" value { "," value } ")"
+//	op         = "=" | "!=" | "<" | "<=" | ">" | ">=" | "~" | "!~"
+//
+// Values are bare words or quoted strings.
+// "~" and "!~" match the value as a regular expression.
+// Comparisons are typed by the field datatype in ADIFfieldInfo:
+// numbers are compared numerically, dates and times chronologically,
+// and the others as case-insensitive strings.
+// A missing field is treated as an empty value.
+// "<", "<=", ">" and ">=" are false for an empty value or a value
+// which is not of the datatype (e.g. a date not in YYYYMMDD).
+//
+// Example:
+//
+//	band = 20m and qso_date >= 20150501 and mode in (FT8, JT65) and call ~ ^JA
+
+// Predicate over an ADIFRecord
+type RecordPredicate func(ADIFRecord) bool
+
+// Errors
+var ErrQuerySyntax = errors.New("query syntax error")
+
+type queryToken struct {
+	// Token text (unquoted for strings)
+	text string
+	// Whether the token was quoted
+	quoted bool
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+// Compile a query expression into a RecordPredicate
+func CompileQuery(expr string) (RecordPredicate, error) {
+	tokens, err := tokenizeQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("%w: empty expression", ErrQuerySyntax)
+	}
+	p := &queryParser{tokens: tokens}
+	pred, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("%w: unexpected %q", ErrQuerySyntax, p.tokens[p.pos].text)
+	}
+	return pred, nil
+}
+
+func isQueryOpChar(c byte) bool {
+	return c == '=' || c == '!' || c == '<' || c == '>' || c == '~'
+}
+
+func isQuerySpecialChar(c byte) bool {
+	return c == '(' || c == ')' || c == ',' || c == '"' || c == '\'' ||
+		c == ' ' || c == '\t' || c == '\r' || c == '\n' || isQueryOpChar(c)
+}
+
+func tokenizeQuery(s string) ([]queryToken, error) {
+	tokens := make([]queryToken, 0, 16)
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '(' || c == ')' || c == ',':
+			tokens = append(tokens, queryToken{text: string(c)})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unterminated string", ErrQuerySyntax)
+			}
+			tokens = append(tokens, queryToken{text: s[i+1 : i+1+end], quoted: true})
+			i += end + 2
+		case isQueryOpChar(c):
+			j := i + 1
+			if j < len(s) && (s[j] == '=' || (c == '!' && s[j] == '~')) {
+				j++
+			}
+			tokens = append(tokens, queryToken{text: s[i:j]})
+			i = j
+		default:
+			j := i
+			for j < len(s) && !isQuerySpecialChar(s[j]) {
+				j++
+			}
+			tokens = append(tokens, queryToken{text: s[i:j]})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+func (p *queryParser) peek() (queryToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return queryToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *queryParser) next() (queryToken, error) {
+	tok, ok := p.peek()
+	if !ok {
+		return tok, fmt.Errorf("%w: unexpected end of expression", ErrQuerySyntax)
+	}
+	p.pos++
+	return tok, nil
+}
+
+// Check whether the next token is the given unquoted keyword
+func (p *queryParser) isKeyword(keyword string) bool {
+	tok, ok := p.peek()
+	return ok && !tok.quoted && strings.EqualFold(tok.text, keyword)
+}
+
+func (p *queryParser) expect(text string) error {
+	tok, err := p.next()
+	if err != nil {
+		return err
+	}
+	if tok.quoted || tok.text != text {
+		return fmt.Errorf("%w: expected %q, got %q", ErrQuerySyntax, text, tok.text)
+	}
+	return nil
+}
+
+func (p *queryParser) parseExpr() (RecordPredicate, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("or") {
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(r ADIFRecord) bool { return l(r) || right(r) }
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseTerm() (RecordPredicate, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("and") {
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(r ADIFRecord) bool { return l(r) && right(r) }
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseFactor() (RecordPredicate, error) {
+	if p.isKeyword("not") {
+		p.pos++
+		inner, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return func(r ADIFRecord) bool { return !inner(r) }, nil
+	}
+	if tok, ok := p.peek(); ok && !tok.quoted && tok.text == "(" {
+		p.pos++
+		inner, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *queryParser) parseValue() (string, error) {
+	tok, err := p.next()
+	if err != nil {
+		return "", err
+	}
+	if !tok.quoted && (tok.text == "(" || tok.text == ")" ||
+		tok.text == "," || isQueryOpChar(tok.text[0])) {
+		return "", fmt.Errorf("%w: expected value, got %q", ErrQuerySyntax, tok.text)
+	}
+	return tok.text, nil
+}
+
+func (p *queryParser) parseComparison() (RecordPredicate, error) {
+	field, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	field = strings.ToLower(field)
+
+	if p.isKeyword("in") {
+		p.pos++
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		values := make([]string, 0, 4)
+		for {
+			v, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+			tok, err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			if tok.text == ")" && !tok.quoted {
+				break
+			}
+			if tok.text != "," || tok.quoted {
+				return nil, fmt.Errorf("%w: expected \",\" or \")\", got %q", ErrQuerySyntax, tok.text)
+			}
+		}
+		return func(r ADIFRecord) bool {
+			v := queryFieldValue(r, field)
+			for _, want := range values {
+				if c, _ := compareFieldValues(field, v, want); c == 0 {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if op.quoted || !isQueryOpChar(op.text[0]) {
+		return nil, fmt.Errorf("%w: expected operator, got %q", ErrQuerySyntax, op.text)
+	}
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.text {
+	case "~", "!~":
+		re, err := regexp.Compile("(?i)" + value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrQuerySyntax, err)
+		}
+		negate := op.text == "!~"
+		return func(r ADIFRecord) bool {
+			return re.MatchString(queryFieldValue(r, field)) != negate
+		}, nil
+	case "=":
+		return func(r ADIFRecord) bool {
+			c, _ := compareFieldValues(field, queryFieldValue(r, field), value)
+			return c == 0
+		}, nil
+	case "!=":
+		return func(r ADIFRecord) bool {
+			c, _ := compareFieldValues(field, queryFieldValue(r, field), value)
+			return c != 0
+		}, nil
+	case "<":
+		return func(r ADIFRecord) bool {
+			c, ok := compareFieldValues(field, queryFieldValue(r, field), value)
+			return ok && c < 0
+		}, nil
+	case "<=":
+		return func(r ADIFRecord) bool {
+			c, ok := compareFieldValues(field, queryFieldValue(r, field), value)
+			return ok && c <= 0
+		}, nil
+	case ">":
+		return func(r ADIFRecord) bool {
+			c, ok := compareFieldValues(field, queryFieldValue(r, field), value)
+			return ok && c > 0
+		}, nil
+	case ">=":
+		return func(r ADIFRecord) bool {
+			c, ok := compareFieldValues(field, queryFieldValue(r, field), value)
+			return ok && c >= 0
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown operator %q", ErrQuerySyntax, op.text)
+}
+
+func queryFieldValue(r ADIFRecord, field string) string {
+	v, err := r.GetValue(field)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(v)
+}
+
+// Compare two values of the field, typed by the field datatype
+// Returns -1, 0, or 1, and whether both values are valid for the datatype;
+// invalid values, including the empty one of a missing field,
+// are compared as strings and are not ordered
+func compareFieldValues(field, a, b string) (int, bool) {
+	datatype := ADIFString
+	if info, ok := ADIFfieldInfo[field]; ok {
+		datatype = info.datatype
+	}
+	switch datatype {
+	case ADIFNumber:
+		fa, erra := strconv.ParseFloat(a, 64)
+		fb, errb := strconv.ParseFloat(b, 64)
+		if erra == nil && errb == nil {
+			switch {
+			case fa < fb:
+				return -1, true
+			case fa > fb:
+				return 1, true
+			}
+			return 0, true
+		}
+	case ADIFTime:
+		// HHMM is equivalent to HHMM00
+		if len(a) == 4 {
+			a += "00"
+		}
+		if len(b) == 4 {
+			b += "00"
+		}
+		_, erra := time.Parse(adifTimeLayout, a)
+		_, errb := time.Parse(adifTimeLayout, b)
+		return strings.Compare(a, b), erra == nil && errb == nil
+	case ADIFDate:
+		_, erra := time.Parse(adifDateLayout, a)
+		_, errb := time.Parse(adifDateLayout, b)
+		return strings.Compare(a, b), erra == nil && errb == nil
+	default:
+		return strings.Compare(strings.ToUpper(a), strings.ToUpper(b)), a != "" && b != ""
+	}
+	return strings.Compare(strings.ToUpper(a), strings.ToUpper(b)), false
+}
+
+// ADIFReader wrapper which only returns the records
+// matching the predicate
+type filterADIFReader struct {
+	// Underlying reader
+	rdr ADIFReader
+	// Matching condition
+	match RecordPredicate
+	// Record count of the matched records
+	records int
+}
+
+// Create a new filtering reader
+func NewFilterADIFReader(r ADIFReader, match RecordPredicate) *filterADIFReader {
+	reader := &filterADIFReader{}
+	reader.rdr = r
+	reader.match = match
+	reader.records = 0
+	return reader
+}
+
+func (ardr *filterADIFReader) ReadRecord() (ADIFRecord, error) {
+	for {
+		record, err := ardr.rdr.ReadRecord()
+		if err != nil {
+			return nil, err
+		}
+		if ardr.match(record) {
+			ardr.records++
+			return record, nil
+		}
+	}
+}
+
+func (ardr *filterADIFReader) RecordCount() int {
+	return ardr.records
+}
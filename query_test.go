@@ -0,0 +1,105 @@
+package adifparser
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func testQuery(t *testing.T, expr string, data string, expected bool) {
+	pred, err := CompileQuery(expr)
+	if err != nil {
+		t.Fatalf("%s: %v", expr, err)
+	}
+	record, err := NewADIFReader(strings.NewReader(data)).ReadRecord()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pred(record) != expected {
+		t.Fatalf("%s: expected %t for %s", expr, expected, data)
+	}
+}
+
+func TestQueryComparisons(t *testing.T) {
+	data := "<call:6>JA1XYZ<band:3>20m<mode:3>FT8<qso_date:8>20150523" +
+		"<time_on:4>0247<freq:9>14.076492<eor>"
+	testQuery(t, "band = 20M", data, true)
+	testQuery(t, "band != 20m", data, false)
+	testQuery(t, "qso_date >= 20150501", data, true)
+	testQuery(t, "qso_date < 20150501", data, false)
+	testQuery(t, "time_on = 024700", data, true)
+	testQuery(t, "freq > 14.07", data, true)
+	testQuery(t, "freq <= 7.1", data, false)
+	testQuery(t, "call ~ ^JA", data, true)
+	testQuery(t, "call !~ '^JA'", data, false)
+	testQuery(t, "mode in (FT8, JT65)", data, true)
+	testQuery(t, "mode in (JT9)", data, false)
+	testQuery(t, "station_callsign = ''", data, true)
+}
+
+func TestQueryOrderingInvalid(t *testing.T) {
+	data := "<call:6>JA1XYZ<qso_date:8>2015-5-2<time_on:4>24xx<freq:3>abc<eor>"
+	for _, expr := range []string{
+		// Missing fields
+		"band < 40m", "band >= ''", "tx_pwr < 100", "time_off >= 0000",
+		// Invalid values
+		"qso_date < 20150501", "qso_date >= 20150501",
+		"time_on < 2400", "time_on > 0000", "freq < 14", "freq >= 14",
+		// Invalid operands
+		"call > ''", "qso_date < 2015", "time_on > noon",
+	} {
+		testQuery(t, expr, data, false)
+		testQuery(t, "not "+expr, data, true)
+	}
+	testQuery(t, "tx_pwr != 100", data, true)
+	testQuery(t, "qso_date = 2015-5-2", data, true)
+}
+
+func TestQueryBoolean(t *testing.T) {
+	data := "<call:6>JA1XYZ<band:3>20m<mode:4>JT65<qso_date:8>20150523<eor>"
+	testQuery(t, "band = 20m and qso_date >= 20150501 and "+
+		"mode in (FT8, JT65) and call ~ ^JA", data, true)
+	testQuery(t, "band = 40m or mode = JT65", data, true)
+	testQuery(t, "not (band = 40m or mode = JT65)", data, false)
+	testQuery(t, "band = 40m or mode = JT65 and call ~ ^W", data, false)
+	testQuery(t, "NOT band = 40m AND mode = \"JT65\"", data, true)
+}
+
+func TestQuerySyntaxErrors(t *testing.T) {
+	for _, expr := range []string{
+		"", "band", "band =", "band = 20m and", "(band = 20m",
+		"mode in (FT8", "call ~ '(' ", "band = 20m)", "= 20m"} {
+		if _, err := CompileQuery(expr); err == nil {
+			t.Fatalf("%q: expected a syntax error", expr)
+		}
+	}
+}
+
+func TestFilterADIFReader(t *testing.T) {
+	f, err := os.Open("testdata/wsjtx.adi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	pred, err := CompileQuery("mode = JT9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := NewFilterADIFReader(NewADIFReader(f), pred)
+	for {
+		record, err := reader.ReadRecord()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, _ := record.GetValue("mode"); v != "JT9" {
+			t.Fatalf("Expected mode JT9, got %s", v)
+		}
+	}
+	if reader.RecordCount() == 0 {
+		t.Fatal("Expected JT9 records, got none")
+	}
+}
@@ -0,0 +1,147 @@
+package adifparser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Errors
+var ErrServiceHTTPStatus = errors.New("service HTTP status error")
+var ErrServiceAuth = errors.New("service authentication failed")
+var ErrServiceRequest = errors.New("service request failed")
+
+// Result of uploading a QSO to a logbook service
+type UploadStatus int
+
+const (
+	UploadInserted UploadStatus = iota
+	UploadDuplicate
+	UploadRejected
+)
+
+func (s UploadStatus) String() string {
+	switch s {
+	case UploadInserted:
+		return "inserted"
+	case UploadDuplicate:
+		return "duplicate"
+	case UploadRejected:
+		return "rejected"
+	}
+	return fmt.Sprintf("UploadStatus(%d)", int(s))
+}
+
+// Per-QSO upload result
+type UploadResult struct {
+	Record ADIFRecord
+	Status UploadStatus
+	// Reason of the rejection or the duplicate
+	Reason string
+	// Log ID assigned by the service (if any)
+	LogID string
+}
+
+// Common HTTP state of the logbook service clients
+type serviceClient struct {
+	baseURL    string
+	httpClient *http.Client
+	userAgent  string
+}
+
+// Logbook service client configuration option
+type ServiceClientOption func(*serviceClient)
+
+// Use the endpoint base URL instead of the default one
+func WithServiceBaseURL(baseURL string) ServiceClientOption {
+	return func(c *serviceClient) {
+		c.baseURL = baseURL
+	}
+}
+
+// Use the HTTP client instead of http.DefaultClient
+func WithServiceHTTPClient(client *http.Client) ServiceClientOption {
+	return func(c *serviceClient) {
+		c.httpClient = client
+	}
+}
+
+// Use the User-Agent header value instead of DefaultUserAgent
+func WithServiceUserAgent(userAgent string) ServiceClientOption {
+	return func(c *serviceClient) {
+		c.userAgent = userAgent
+	}
+}
+
+func (c *serviceClient) init(baseURL string, opts []ServiceClientOption) {
+	c.baseURL = baseURL
+	c.httpClient = http.DefaultClient
+	c.userAgent = DefaultUserAgent
+	for _, opt := range opts {
+		opt(c)
+	}
+}
+
+// POST a request body and read the response
+// The response body is returned with the status code
+// for the caller to interpret the service-specific errors
+func (c *serviceClient) post(ctx context.Context, path string,
+	contentType string, body io.Reader) (int, []byte, error) {
+	requrl := strings.TrimSuffix(c.baseURL, "/") + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, requrl, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", c.userAgent)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, err
+	}
+	return resp.StatusCode, data, nil
+}
+
+// POST a form
+func (c *serviceClient) postForm(ctx context.Context, path string,
+	form url.Values) (int, []byte, error) {
+	return c.post(ctx, path, "application/x-www-form-urlencoded",
+		strings.NewReader(form.Encode()))
+}
+
+// Serialize a record as a single-record ADIF text
+func recordADIF(r ADIFRecord) string {
+	return r.ToString() + "<eor>"
+}
+
+// Parse records from an ADIF text
+func parseADIFRecords(data string) ([]ADIFRecord, error) {
+	records := make([]ADIFRecord, 0, 16)
+	for record, err := range NewADIFReader(strings.NewReader(data)).All() {
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Set the upload status fields of the record
+// Inserted and duplicate QSOs are marked as uploaded;
+// rejected QSOs are left unchanged
+func setUploadStatus(r ADIFRecord, prefix string, status UploadStatus, now time.Time) {
+	if status == UploadRejected {
+		return
+	}
+	r.SetValue(prefix+"_qso_upload_status", "Y")
+	r.SetValue(prefix+"_qso_upload_date", now.UTC().Format("20060102"))
+}
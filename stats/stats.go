@@ -0,0 +1,302 @@
+// Package stats computes QSO statistics of ADIF logs
+package stats
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jj1bdx/adifparser"
+)
+
+// Names of the tables
+const (
+	TableBand      = "band"
+	TableMode      = "mode"
+	TableYear      = "year"
+	TableMonth     = "month"
+	TableHour      = "hour"
+	TableContinent = "continent"
+	TableCall      = "call"
+	TableDXCC      = "dxcc"
+)
+
+// Tables in the order of the output
+var TableNames = []string{
+	TableBand, TableMode, TableYear, TableMonth, TableHour,
+	TableContinent, TableCall, TableDXCC,
+}
+
+// QSO count of a key
+type Entry struct {
+	Key string `json:"key"`
+	// COUNTRY of the first QSO for the dxcc table
+	Name  string `json:"name,omitempty"`
+	Count int    `json:"count"`
+}
+
+// QSO counts of a table
+type Table struct {
+	Name    string  `json:"name"`
+	Entries []Entry `json:"entries"`
+}
+
+// QSO statistics
+// QSOs without the field of a table are not counted in the table
+type Report struct {
+	// Number of the QSOs
+	Records int `json:"records"`
+	// First and last QSO times in UTC (nil if no QSO is dated)
+	First *time.Time `json:"first,omitempty"`
+	Last  *time.Time `json:"last,omitempty"`
+	// QSOs without a valid QSO_DATE
+	Undated int `json:"undated"`
+	// Counts by table and key
+	Counts map[string]map[string]int `json:"-"`
+	// COUNTRY by DXCC entity code
+	Countries map[string]string `json:"-"`
+}
+
+// Statistics collector
+type collector struct {
+	report *Report
+}
+
+// Create a new statistics collector
+func NewCollector() *collector {
+	c := &collector{report: &Report{}}
+	c.report.Counts = make(map[string]map[string]int)
+	for _, t := range TableNames {
+		c.report.Counts[t] = make(map[string]int)
+	}
+	c.report.Countries = make(map[string]string)
+	return c
+}
+
+// QSO time of QSO_DATE and TIME_ON in UTC
+// TIME_ON is either HHMM or HHMMSS; the time is 00:00 if TIME_ON is invalid,
+// and hasTime is false
+func QSOTime(r adifparser.ADIFRecord) (t time.Time, hasTime bool, err error) {
+	date, err := time.Parse("20060102", adifparser.RecordValue(r, "qso_date"))
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	on := adifparser.RecordValue(r, "time_on")
+	for _, layout := range []string{"150405", "1504"} {
+		if len(on) != len(layout) {
+			continue
+		}
+		if tm, err := time.Parse(layout, on); err == nil {
+			return date.Add(time.Duration(tm.Hour())*time.Hour +
+				time.Duration(tm.Minute())*time.Minute +
+				time.Duration(tm.Second())*time.Second), true, nil
+		}
+	}
+	return date, false, nil
+}
+
+// Add a QSO
+func (c *collector) Add(r adifparser.ADIFRecord) {
+	report := c.report
+	report.Records++
+	count := func(table, key string) {
+		if key != "" {
+			report.Counts[table][key]++
+		}
+	}
+	count(TableBand, adifparser.RecordBand(r))
+	count(TableMode, strings.ToUpper(adifparser.RecordValue(r, "mode")))
+	count(TableContinent, strings.ToUpper(adifparser.RecordValue(r, "cont")))
+	count(TableCall, strings.ToUpper(adifparser.RecordValue(r, "call")))
+	if code := adifparser.DXCCCode(adifparser.RecordValue(r, "dxcc")); code != "" {
+		count(TableDXCC, code)
+		if report.Countries[code] == "" {
+			report.Countries[code] = adifparser.RecordValue(r, "country")
+		}
+	}
+
+	t, hasTime, err := QSOTime(r)
+	if err != nil {
+		report.Undated++
+		return
+	}
+	count(TableYear, t.Format("2006"))
+	count(TableMonth, t.Format("2006-01"))
+	if hasTime {
+		count(TableHour, t.Format("15"))
+	}
+	if report.First == nil || t.Before(*report.First) {
+		first := t
+		report.First = &first
+	}
+	if report.Last == nil || t.After(*report.Last) {
+		last := t
+		report.Last = &last
+	}
+}
+
+// Add all the QSOs of the reader
+func (c *collector) AddAll(rdr adifparser.ADIFReader) error {
+	for record, err := range adifparser.AllRecords(rdr) {
+		if err != nil {
+			return err
+		}
+		c.Add(record)
+	}
+	return nil
+}
+
+// Statistics of the QSOs added
+// The report is a copy not updated by the subsequent Add calls
+func (c *collector) Report() *Report {
+	report := *c.report
+	if c.report.First != nil {
+		first, last := *c.report.First, *c.report.Last
+		report.First, report.Last = &first, &last
+	}
+	report.Counts = make(map[string]map[string]int, len(c.report.Counts))
+	for name, counts := range c.report.Counts {
+		report.Counts[name] = make(map[string]int, len(counts))
+		for k, n := range counts {
+			report.Counts[name][k] = n
+		}
+	}
+	report.Countries = make(map[string]string, len(c.report.Countries))
+	for k, v := range c.report.Countries {
+		report.Countries[k] = v
+	}
+	return &report
+}
+
+// Entries of the table
+// Bands are in the frequency order, years, months and hours in the time
+// order, continents and modes in the name order, and calls and DXCC entities
+// in the descending count order
+func (report *Report) Table(name string) Table {
+	table := Table{Name: name, Entries: []Entry{}}
+	for k, n := range report.Counts[name] {
+		e := Entry{Key: k, Count: n}
+		if name == TableDXCC {
+			e.Name = report.Countries[k]
+		}
+		table.Entries = append(table.Entries, e)
+	}
+	entries := table.Entries
+	switch name {
+	case TableBand:
+		sort.Slice(entries, func(i, j int) bool {
+			bi := adifparser.BandIndex(entries[i].Key)
+			bj := adifparser.BandIndex(entries[j].Key)
+			if bi < 0 || bj < 0 {
+				if bi != bj {
+					return bj < 0
+				}
+				return entries[i].Key < entries[j].Key
+			}
+			return bi < bj
+		})
+	case TableCall, TableDXCC:
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].Count != entries[j].Count {
+				return entries[i].Count > entries[j].Count
+			}
+			if name == TableDXCC {
+				a, _ := strconv.Atoi(entries[i].Key)
+				b, _ := strconv.Atoi(entries[j].Key)
+				return a < b
+			}
+			return entries[i].Key < entries[j].Key
+		})
+	default:
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Key < entries[j].Key
+		})
+	}
+	return table
+}
+
+// All the tables; the call and DXCC tables are limited to the top entries
+// (no limit if top is 0)
+func (report *Report) Tables(top int) []Table {
+	tables := []Table{}
+	for _, name := range TableNames {
+		table := report.Table(name)
+		if (name == TableCall || name == TableDXCC) &&
+			top > 0 && len(table.Entries) > top {
+			table.Entries = table.Entries[:top]
+		}
+		tables = append(tables, table)
+	}
+	return tables
+}
+
+// Time in the output ("" for nil)
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// Write the statistics as text tables
+func (report *Report) WriteText(w io.Writer, top int) error {
+	fmt.Fprintf(w, "QSOs: %d\n", report.Records)
+	fmt.Fprintf(w, "First: %s\n", formatTime(report.First))
+	fmt.Fprintf(w, "Last: %s\n", formatTime(report.Last))
+	fmt.Fprintf(w, "Undated: %d\n", report.Undated)
+	for _, table := range report.Tables(top) {
+		fmt.Fprintln(w)
+		tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+		fmt.Fprintf(tw, "%s\tQSOs\n", strings.ToUpper(table.Name))
+		for _, e := range table.Entries {
+			key := e.Key
+			if e.Name != "" {
+				key += " " + e.Name
+			}
+			fmt.Fprintf(tw, "%s\t%d\n", key, e.Count)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write the statistics as CSV with the columns table, key, name and count
+// The summary is in the rows of the table "summary"
+func (report *Report) WriteCSV(w io.Writer, top int) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"table", "key", "name", "count"})
+	for _, row := range [][2]string{
+		{"records", strconv.Itoa(report.Records)},
+		{"first", formatTime(report.First)},
+		{"last", formatTime(report.Last)},
+		{"undated", strconv.Itoa(report.Undated)},
+	} {
+		cw.Write([]string{"summary", row[0], "", row[1]})
+	}
+	for _, table := range report.Tables(top) {
+		for _, e := range table.Entries {
+			cw.Write([]string{table.Name, e.Key, e.Name, strconv.Itoa(e.Count)})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Write the statistics as a JSON object with the summary and the tables
+func (report *Report) WriteJSON(w io.Writer, top int) error {
+	out := struct {
+		*Report
+		Tables []Table `json:"tables"`
+	}{report, report.Tables(top)}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
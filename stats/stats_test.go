@@ -0,0 +1,165 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jj1bdx/adifparser"
+)
+
+const statsTestData = "<call:4>W1AW<qso_date:8>20230624<time_on:4>1830<band:3>20m<mode:2>CW" +
+	"<cont:2>NA<dxcc:3>291<country:24>United States of America<eor>" +
+	"<call:4>w1aw<qso_date:8>20240101<time_on:6>000512<freq:5>7.020<mode:2>CW" +
+	"<cont:2>na<dxcc:3>291<eor>" +
+	"<call:5>JA1XX<qso_date:8>20231231<time_on:4>2359<band:3>20m<mode:3>FT8" +
+	"<cont:2>AS<dxcc:3>339<country:5>Japan<eor>" +
+	"<call:5>VK9XX<qso_date:8>20230101<time_on:2>12<band:3>30m<mode:3>SSB<dxcc:3>034<eor>" +
+	"<call:4>X1XX<qso_date:8>20231301<band:2>2m<mode:2>FM<eor>"
+
+func testReport(t *testing.T) *Report {
+	t.Helper()
+	c := NewCollector()
+	if err := c.AddAll(adifparser.NewADIFReader(strings.NewReader(statsTestData))); err != nil {
+		t.Fatal(err)
+	}
+	return c.Report()
+}
+
+func tableString(table Table) string {
+	s := []string{}
+	for _, e := range table.Entries {
+		s = append(s, e.Key+"="+strings.Repeat("x", e.Count))
+	}
+	return strings.Join(s, ",")
+}
+
+func TestCollector(t *testing.T) {
+	report := testReport(t)
+	if report.Records != 5 || report.Undated != 1 {
+		t.Fatalf("Unexpected records %d or undated %d", report.Records, report.Undated)
+	}
+	if !report.First.Equal(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)) ||
+		!report.Last.Equal(time.Date(2024, 1, 1, 0, 5, 12, 0, time.UTC)) {
+		t.Fatalf("Unexpected first %v or last %v", report.First, report.Last)
+	}
+	for name, expected := range map[string]string{
+		TableBand:      "40m=x,30m=x,20m=xx,2m=x",
+		TableMode:      "CW=xx,FM=x,FT8=x,SSB=x",
+		TableYear:      "2023=xxx,2024=x",
+		TableMonth:     "2023-01=x,2023-06=x,2023-12=x,2024-01=x",
+		TableHour:      "00=x,18=x,23=x",
+		TableContinent: "AS=x,NA=xx",
+		TableCall:      "W1AW=xx,JA1XX=x,VK9XX=x,X1XX=x",
+		TableDXCC:      "291=xx,34=x,339=x",
+	} {
+		if got := tableString(report.Table(name)); got != expected {
+			t.Fatalf("%s: expected %s, got %s", name, expected, got)
+		}
+	}
+	if name := report.Table(TableDXCC).Entries[0].Name; name != "United States of America" {
+		t.Fatalf("Unexpected DXCC name %q", name)
+	}
+
+	tables := report.Tables(2)
+	if len(tables) != len(TableNames) {
+		t.Fatalf("Unexpected tables %v", tables)
+	}
+	for _, table := range tables {
+		if table.Name == TableCall && tableString(table) != "W1AW=xx,JA1XX=x" {
+			t.Fatalf("Unexpected top calls %v", table)
+		}
+	}
+}
+
+func TestCollectorReportCopy(t *testing.T) {
+	c := NewCollector()
+	r := adifparser.NewADIFRecord()
+	r.SetValue("call", "W1AW")
+	r.SetValue("qso_date", "20230624")
+	c.Add(r)
+	report := c.Report()
+	r.SetValue("qso_date", "20240101")
+	c.Add(r)
+	if report.Records != 1 || report.Counts[TableCall]["W1AW"] != 1 ||
+		!report.Last.Equal(time.Date(2023, 6, 24, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Report changed by Add: %+v", report)
+	}
+	if c.Report().Counts[TableCall]["W1AW"] != 2 {
+		t.Fatal("Report not updated")
+	}
+}
+
+func TestQSOTime(t *testing.T) {
+	for _, c := range []struct {
+		date, on string
+		expected time.Time
+		hasTime  bool
+		err      bool
+	}{
+		{"20230624", "1830", time.Date(2023, 6, 24, 18, 30, 0, 0, time.UTC), true, false},
+		{"20230624", "183015", time.Date(2023, 6, 24, 18, 30, 15, 0, time.UTC), true, false},
+		{"20230624", "2460", time.Date(2023, 6, 24, 0, 0, 0, 0, time.UTC), false, false},
+		{"20230624", "", time.Date(2023, 6, 24, 0, 0, 0, 0, time.UTC), false, false},
+		{"2023-06-24", "1830", time.Time{}, false, true},
+	} {
+		r := adifparser.NewADIFRecord()
+		r.SetValue("qso_date", c.date)
+		r.SetValue("time_on", c.on)
+		got, hasTime, err := QSOTime(r)
+		if (err != nil) != c.err || hasTime != c.hasTime || !got.Equal(c.expected) {
+			t.Fatalf("%s %s: expected %v %v %v, got %v %v %v", c.date, c.on,
+				c.expected, c.hasTime, c.err, got, hasTime, err)
+		}
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	report := testReport(t)
+
+	var buf bytes.Buffer
+	if err := report.WriteText(&buf, 1); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"QSOs: 5\nFirst: 2023-01-01 00:00\nLast: 2024-01-01 00:05\nUndated: 1\n",
+		"\nBAND QSOs\n40m  1\n30m  1\n20m  2\n2m   1\n",
+		"\nDXCC                         QSOs\n291 United States of America 2\n",
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Fatalf("Expected %q in:\n%s", s, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := report.WriteCSV(&buf, 1); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"table,key,name,count\nsummary,records,,5\nsummary,first,,2023-01-01 00:00\n",
+		"\ncall,W1AW,,2\ndxcc,291,United States of America,2\n",
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Fatalf("Expected %q in:\n%s", s, buf.String())
+		}
+	}
+
+	buf.Reset()
+	if err := report.WriteJSON(&buf, 0); err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Records int       `json:"records"`
+		First   time.Time `json:"first"`
+		Tables  []Table   `json:"tables"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Records != 5 || !out.First.Equal(*report.First) ||
+		len(out.Tables) != len(TableNames) || out.Tables[0].Name != TableBand {
+		t.Fatalf("Unexpected JSON %s", buf.String())
+	}
+}
@@ -0,0 +1,387 @@
+package adifparser
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// Signed upload file generation for LoTW
+//
+// The output is in the GABBI format produced by TQSL:
+// a tCERT record with the callsign certificate,
+// a tSTATION record with the station location, and
+// a tCONTACT record for each QSO, signed by the private key
+// with SIGN_LOTW_V2.0 (RSA PKCS #1 v1.5 over SHA-1).
+// A .tq7 file is the plain GABBI text, and a .tq8 file is gzipped .tq7.
+
+// Default TQSL_IDENT value identifying the producer of the file
+// Set the one of the application by SetComment
+const DefaultTQSLIdent string = "adifparser"
+
+// Errors
+var ErrTQSLPKCS12 = errors.New("invalid PKCS #12 file")
+var ErrTQSLNoCertificate = errors.New("no certificate found")
+var ErrTQSLNoPrivateKey = errors.New("no RSA private key found")
+var ErrTQSLKeyMismatch = errors.New("private key does not match the certificate")
+var ErrTQSLCallsign = errors.New("station callsign does not match the certificate")
+var ErrTQSLMissingField = errors.New("required QSO field missing")
+
+// LoTW callsign certificate OID
+var oidLOTWCallsign = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 12348, 1, 1}
+
+// Callsign certificate with the private key
+type TQSLCertificate struct {
+	Certificate *x509.Certificate
+	PrivateKey  *rsa.PrivateKey
+}
+
+// Callsign of the certificate (empty if not a LoTW certificate)
+func (c *TQSLCertificate) Callsign() string {
+	for _, name := range c.Certificate.Subject.Names {
+		if name.Type.Equal(oidLOTWCallsign) {
+			if s, ok := name.Value.(string); ok {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// Load a callsign certificate and the private key from a file
+// A .p12 or .pfx file (as exported by TQSL) is decoded as PKCS #12
+// with the password; other files are decoded as PEM
+func LoadTQSLCertificate(path, password string) (*TQSLCertificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".p12", ".pfx":
+		return ParseTQSLCertificatePKCS12(data, password)
+	}
+	return ParseTQSLCertificatePEM(data)
+}
+
+// Parse a callsign certificate and the private key in PKCS #12
+// The certificate matching the private key is used as the callsign certificate;
+// the other certificates of the chain are ignored
+func ParseTQSLCertificatePKCS12(data []byte, password string) (*TQSLCertificate, error) {
+	key, leaf, chain, err := pkcs12.DecodeChain(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTQSLPKCS12, err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrTQSLNoPrivateKey
+	}
+	for _, c := range append([]*x509.Certificate{leaf}, chain...) {
+		if rsaKey.PublicKey.Equal(c.PublicKey) {
+			return &TQSLCertificate{Certificate: c, PrivateKey: rsaKey}, nil
+		}
+	}
+	return nil, ErrTQSLKeyMismatch
+}
+
+// Parse a callsign certificate and the private key in PEM
+// The first certificate is used as the callsign certificate
+func ParseTQSLCertificatePEM(data []byte) (*TQSLCertificate, error) {
+	cert := &TQSLCertificate{}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		switch block.Type {
+		case "CERTIFICATE":
+			if cert.Certificate != nil {
+				continue
+			}
+			c, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			cert.Certificate = c
+		case "RSA PRIVATE KEY":
+			key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			cert.PrivateKey = key
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			rsaKey, ok := key.(*rsa.PrivateKey)
+			if !ok {
+				return nil, ErrTQSLNoPrivateKey
+			}
+			cert.PrivateKey = rsaKey
+		}
+	}
+	if cert.Certificate == nil {
+		return nil, ErrTQSLNoCertificate
+	}
+	if cert.PrivateKey == nil {
+		return nil, ErrTQSLNoPrivateKey
+	}
+	if !cert.PrivateKey.PublicKey.Equal(cert.Certificate.PublicKey) {
+		return nil, ErrTQSLKeyMismatch
+	}
+	return cert, nil
+}
+
+// Station location data
+type TQSLStation struct {
+	Call       string
+	DXCC       string
+	GridSquare string
+	ITUZ       string
+	CQZ        string
+	IOTA       string
+	// Other GABBI station fields
+	// (e.g. "US_STATE", "US_COUNTY", "CA_PROVINCE", "JA_PREFECTURE")
+	Fields map[string]string
+}
+
+// Station fields in the order of the LoTW V2.0 signature
+var tqslStationSignFields = []string{
+	"AU_STATE", "CA_PROVINCE", "CA_US_PARK", "CN_PROVINCE", "CQZ",
+	"DX_US", "FI_KUNTA", "GRIDSQUARE", "IOTA", "ITUZ",
+	"JA_CITY_GUN_KU", "JA_PREFECTURE", "RU_OBLAST",
+	"US_COUNTY", "US_PARK", "US_STATE",
+}
+
+// Contact fields in the order of the LoTW V2.0 signature
+var tqslContactSignFields = []string{
+	"BAND", "BAND_RX", "CALL", "FREQ", "FREQ_RX",
+	"MODE", "PROP_MODE", "QSO_DATE", "QSO_TIME", "SAT_NAME",
+}
+
+// All station fields by GABBI name
+func (s *TQSLStation) values() map[string]string {
+	values := map[string]string{}
+	for k, v := range s.Fields {
+		values[strings.ToUpper(k)] = v
+	}
+	for k, v := range map[string]string{
+		"CALL": s.Call, "DXCC": s.DXCC, "GRIDSQUARE": s.GridSquare,
+		"ITUZ": s.ITUZ, "CQZ": s.CQZ, "IOTA": s.IOTA} {
+		if v != "" {
+			values[k] = v
+		}
+	}
+	return values
+}
+
+// Writer of the signed upload file
+type tqslWriter struct {
+	writer *bufio.Writer
+	// gzip writer for .tq8 (nil for .tq7)
+	gz      *gzip.Writer
+	cert    *TQSLCertificate
+	station TQSLStation
+	ident   string
+	started bool
+}
+
+// Create a new writer for a .tq7 file
+func NewTQ7Writer(w io.Writer, cert *TQSLCertificate, station TQSLStation) (*tqslWriter, error) {
+	if call := cert.Callsign(); call != "" && !strings.EqualFold(call, station.Call) {
+		return nil, fmt.Errorf("%w: %s and %s", ErrTQSLCallsign, station.Call, call)
+	}
+	writer := &tqslWriter{}
+	writer.writer = bufio.NewWriter(w)
+	writer.cert = cert
+	writer.station = station
+	writer.ident = DefaultTQSLIdent
+	return writer, nil
+}
+
+// Create a new writer for a .tq8 file
+// Close must be called to finish the file
+func NewTQ8Writer(w io.Writer, cert *TQSLCertificate, station TQSLStation) (*tqslWriter, error) {
+	gz := gzip.NewWriter(w)
+	writer, err := NewTQ7Writer(gz, cert, station)
+	if err != nil {
+		return nil, err
+	}
+	writer.gz = gz
+	return writer, nil
+}
+
+// Write a GABBI field
+func (writer *tqslWriter) writeField(name, value string) {
+	fmt.Fprintf(writer.writer, "<%s:%d>%s\n", name, len(value), value)
+}
+
+// Base64 with line breaks as in PEM
+func wrappedBase64(b []byte) string {
+	s := base64.StdEncoding.EncodeToString(b)
+	var buf bytes.Buffer
+	for len(s) > 64 {
+		buf.WriteString(s[:64])
+		buf.WriteByte('\n')
+		s = s[64:]
+	}
+	buf.WriteString(s)
+	buf.WriteByte('\n')
+	return buf.String()
+}
+
+// Write the tCERT and tSTATION records
+func (writer *tqslWriter) writeHeader() error {
+	if writer.started {
+		return nil
+	}
+	writer.started = true
+	writer.writeField("TQSL_IDENT", writer.ident)
+	writer.writer.WriteString("\n")
+
+	writer.writeField("Rec_Type", "tCERT")
+	writer.writeField("CERT_UID", "1")
+	writer.writeField("CERTIFICATE", wrappedBase64(writer.cert.Certificate.Raw))
+	writer.writer.WriteString("<eor>\n\n")
+
+	writer.writeField("Rec_Type", "tSTATION")
+	writer.writeField("STATION_UID", "1")
+	writer.writeField("CERT_UID", "1")
+	values := writer.station.values()
+	for _, k := range append([]string{"CALL", "DXCC"}, tqslStationSignFields...) {
+		if v, ok := values[k]; ok {
+			writer.writeField(k, strings.ToUpper(v))
+		}
+	}
+	_, err := writer.writer.WriteString("<eor>\n\n")
+	return err
+}
+
+// Convert a record to the GABBI contact fields
+func tqslContactValues(r ADIFRecord) (map[string]string, error) {
+	values := map[string]string{}
+	get := func(name string) string {
+		v, _ := r.GetValue(name)
+		return strings.ToUpper(strings.TrimSpace(v))
+	}
+	for _, k := range []string{"call", "band", "qso_date", "time_on"} {
+		if get(k) == "" {
+			return nil, fmt.Errorf("%w: %s", ErrTQSLMissingField, k)
+		}
+	}
+	mode := get("submode")
+	if mode == "" {
+		mode = get("mode")
+	}
+	if mode == "" {
+		return nil, fmt.Errorf("%w: mode", ErrTQSLMissingField)
+	}
+	date, t := get("qso_date"), get("time_on")
+	if len(date) != 8 || (len(t) != 4 && len(t) != 6) {
+		return nil, fmt.Errorf("%w: invalid date/time %s %s", ErrTQSLMissingField, date, t)
+	}
+	if len(t) == 4 {
+		t += "00"
+	}
+	values["CALL"] = get("call")
+	values["BAND"] = get("band")
+	values["MODE"] = mode
+	values["QSO_DATE"] = date[:4] + "-" + date[4:6] + "-" + date[6:]
+	values["QSO_TIME"] = t[:2] + ":" + t[2:4] + ":" + t[4:] + "Z"
+	for gabbi, adif := range map[string]string{
+		"BAND_RX": "band_rx", "FREQ": "freq", "FREQ_RX": "freq_rx",
+		"PROP_MODE": "prop_mode", "SAT_NAME": "sat_name"} {
+		if v := get(adif); v != "" {
+			values[gabbi] = v
+		}
+	}
+	return values, nil
+}
+
+// Sign and write a tCONTACT record
+func (writer *tqslWriter) WriteRecord(r ADIFRecord) error {
+	contact, err := tqslContactValues(r)
+	if err != nil {
+		return err
+	}
+	if err := writer.writeHeader(); err != nil {
+		return err
+	}
+
+	var signdata strings.Builder
+	station := writer.station.values()
+	for _, k := range tqslStationSignFields {
+		signdata.WriteString(strings.ToUpper(strings.TrimSpace(station[k])))
+	}
+	for _, k := range tqslContactSignFields {
+		signdata.WriteString(contact[k])
+	}
+	digest := sha1.Sum([]byte(signdata.String()))
+	sig, err := rsa.SignPKCS1v15(nil, writer.cert.PrivateKey, crypto.SHA1, digest[:])
+	if err != nil {
+		return err
+	}
+
+	writer.writeField("Rec_Type", "tCONTACT")
+	writer.writeField("STATION_UID", "1")
+	for _, k := range []string{"CALL", "BAND", "BAND_RX", "MODE", "FREQ",
+		"FREQ_RX", "PROP_MODE", "SAT_NAME", "QSO_DATE", "QSO_TIME"} {
+		if v, ok := contact[k]; ok {
+			writer.writeField(k, v)
+		}
+	}
+	s := wrappedBase64(sig)
+	fmt.Fprintf(writer.writer, "<SIGN_LOTW_V2.0:%d:6>%s", len(s), s)
+	writer.writeField("SIGNDATA", signdata.String())
+	_, err = writer.writer.WriteString("<eor>\n\n")
+	return err
+}
+
+func (writer *tqslWriter) Flush() error {
+	if err := writer.writeHeader(); err != nil {
+		return err
+	}
+	if err := writer.writer.Flush(); err != nil {
+		return err
+	}
+	if writer.gz != nil {
+		return writer.gz.Flush()
+	}
+	return nil
+}
+
+// Set the TQSL_IDENT value
+func (writer *tqslWriter) SetComment(comment string) error {
+	if writer.started {
+		return ErrOutputStarted
+	}
+	writer.ident = comment
+	return nil
+}
+
+// Flush and finish the file
+func (writer *tqslWriter) Close() error {
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	if writer.gz != nil {
+		return writer.gz.Close()
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package adifparser
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var testTQSLStation = TQSLStation{
+	Call:       "JJ1BDX",
+	DXCC:       "339",
+	GridSquare: "PM95vq",
+	ITUZ:       "45",
+	CQZ:        "25",
+	Fields:     map[string]string{"ja_prefecture": "Tokyo"},
+}
+
+const testTQSLRecords = "<call:5>KL3MM<band:3>20m<mode:4>JT65<qso_date:8>20150523" +
+	"<time_on:4>0247<freq:9>14.076492<eor>" +
+	"<call:4>W1AW<band:3>40m<mode:4>MFSK<submode:3>FT4<qso_date:8>20210107" +
+	"<time_on:6>015400<eor>" +
+	"<call:5>K1ABC<band:2>2m<band_rx:4>70cm<freq:7>145.950<freq_rx:7>435.850" +
+	"<mode:3>SSB<prop_mode:3>SAT<sat_name:4>AO-7<qso_date:8>20210108" +
+	"<time_on:4>2359<eor>"
+
+// SIGNDATA of testTQSLRecords, written by hand after the QSO sigspec of
+// the TQSL configuration: the station fields, then the contact fields,
+// each in the name order, upper case
+var testTQSLSignData = []string{
+	"25PM95VQ45TOKYO" + "20MKL3MM14.076492JT652015-05-2302:47:00Z",
+	"25PM95VQ45TOKYO" + "40MW1AWFT42021-01-0701:54:00Z",
+	"25PM95VQ45TOKYO" + "2M70CMK1ABC145.950435.850SSBSAT2021-01-0823:59:00ZAO-7",
+}
+
+func writeTestTQSL(t *testing.T, w io.Writer, tq8 bool) {
+	cert, err := LoadTQSLCertificate("testdata/tqsl_test.pem", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var writer *tqslWriter
+	if tq8 {
+		writer, err = NewTQ8Writer(w, cert, testTQSLStation)
+	} else {
+		writer, err = NewTQ7Writer(w, cert, testTQSLStation)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	for record, err := range NewADIFReader(strings.NewReader(testTQSLRecords)).All() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := writer.WriteRecord(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTQ7Writer(t *testing.T) {
+	var buf bytes.Buffer
+	writeTestTQSL(t, &buf, false)
+
+	cert, _ := LoadTQSLCertificate("testdata/tqsl_test.pem", "")
+	out := buf.String()
+	for _, part := range []string{
+		"<TQSL_IDENT:10>adifparser\n\n<Rec_Type:5>tCERT\n<CERT_UID:1>1\n",
+		wrappedBase64(cert.Certificate.Raw) + "\n<eor>\n\n",
+		"<Rec_Type:8>tSTATION\n<STATION_UID:1>1\n<CERT_UID:1>1\n" +
+			"<CALL:6>JJ1BDX\n<DXCC:3>339\n<CQZ:2>25\n<GRIDSQUARE:6>PM95VQ\n" +
+			"<ITUZ:2>45\n<JA_PREFECTURE:5>TOKYO\n<eor>\n\n",
+		"<Rec_Type:8>tCONTACT\n<STATION_UID:1>1\n<CALL:5>KL3MM\n<BAND:3>20M\n" +
+			"<MODE:4>JT65\n<FREQ:9>14.076492\n<QSO_DATE:10>2015-05-23\n" +
+			"<QSO_TIME:9>02:47:00Z\n<SIGN_LOTW_V2.0:",
+		"<CALL:5>K1ABC\n<BAND:2>2M\n<BAND_RX:4>70CM\n<MODE:3>SSB\n" +
+			"<FREQ:7>145.950\n<FREQ_RX:7>435.850\n<PROP_MODE:3>SAT\n" +
+			"<SAT_NAME:4>AO-7\n<QSO_DATE:10>2021-01-08\n<QSO_TIME:9>23:59:00Z\n",
+	} {
+		if !strings.Contains(out, part) {
+			t.Fatalf("Missing %q in output:\n%s", part, out)
+		}
+	}
+
+	// Verify the signatures
+	re := regexp.MustCompile(`<SIGN_LOTW_V2.0:\d+:6>([^<]*)<SIGNDATA:\d+>([^\n]*)\n`)
+	matches := re.FindAllStringSubmatch(out, -1)
+	if len(matches) != len(testTQSLSignData) {
+		t.Fatalf("Expected %d signatures, got %d", len(testTQSLSignData), len(matches))
+	}
+	for i, m := range matches {
+		if m[2] != testTQSLSignData[i] {
+			t.Fatalf("Expected SIGNDATA %s, got %s", testTQSLSignData[i], m[2])
+		}
+		sig, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(m[1], "\n", ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		digest := sha1.Sum([]byte(m[2]))
+		if err := rsa.VerifyPKCS1v15(&cert.PrivateKey.PublicKey, crypto.SHA1, digest[:], sig); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTQSLIdent(t *testing.T) {
+	cert, err := LoadTQSLCertificate("testdata/tqsl_test.pem", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	writer, err := NewTQ7Writer(&buf, cert, testTQSLStation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.SetComment("MyLogger 1.0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "<TQSL_IDENT:12>MyLogger 1.0\n") {
+		t.Fatalf("Unexpected output:\n%s", buf.String())
+	}
+	if err := writer.SetComment("other"); err != ErrOutputStarted {
+		t.Fatalf("Expected %v, got %v", ErrOutputStarted, err)
+	}
+}
+
+func TestTQ8Writer(t *testing.T) {
+	var tq7, tq8 bytes.Buffer
+	writeTestTQSL(t, &tq7, false)
+	writeTestTQSL(t, &tq8, true)
+	gz, err := gzip.NewReader(&tq8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, tq7.Bytes()) {
+		t.Fatal("Uncompressed .tq8 differs from .tq7")
+	}
+}
+
+func TestLoadTQSLCertificatePKCS12(t *testing.T) {
+	// PBE-SHA1-3DES as exported by TQSL, with a CA certificate in the chain
+	cert, err := LoadTQSLCertificate("testdata/tqsl_test.p12", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemCert, _ := LoadTQSLCertificate("testdata/tqsl_test.pem", "")
+	if !cert.Certificate.Equal(pemCert.Certificate) || !cert.PrivateKey.Equal(pemCert.PrivateKey) {
+		t.Fatal("PKCS #12 certificate differs from PEM")
+	}
+	if cert.Callsign() != "JJ1BDX" {
+		t.Fatalf("Unexpected callsign %s", cert.Callsign())
+	}
+}
+
+func TestTQSLErrors(t *testing.T) {
+	if _, err := LoadTQSLCertificate("testdata/tqsl_test.p12", "wrong"); !errors.Is(err, ErrTQSLPKCS12) {
+		t.Fatalf("Expected %v, got %v", ErrTQSLPKCS12, err)
+	}
+	data, err := os.ReadFile("testdata/tqsl_test.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+	certOnly := data[:bytes.Index(data, []byte("-----BEGIN RSA"))]
+	if _, err := ParseTQSLCertificatePEM(certOnly); err != ErrTQSLNoPrivateKey {
+		t.Fatalf("Expected %v, got %v", ErrTQSLNoPrivateKey, err)
+	}
+
+	cert, err := ParseTQSLCertificatePEM(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.Callsign() != "JJ1BDX" {
+		t.Fatalf("Unexpected callsign %s", cert.Callsign())
+	}
+	station := testTQSLStation
+	station.Call = "KF4MDV"
+	if _, err := NewTQ7Writer(io.Discard, cert, station); !errors.Is(err, ErrTQSLCallsign) {
+		t.Fatalf("Expected %v, got %v", ErrTQSLCallsign, err)
+	}
+
+	writer, err := NewTQ7Writer(io.Discard, cert, testTQSLStation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := NewADIFRecord()
+	record.SetValue("call", "W1AW")
+	if err := writer.WriteRecord(record); !errors.Is(err, ErrTQSLMissingField) {
+		t.Fatalf("Expected %v, got %v", ErrTQSLMissingField, err)
+	}
+}
@@ -0,0 +1,197 @@
+package adifparser
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Record transform rules
+//
+// Rules are written one per line; empty lines and lines
+// starting with "#" are ignored.
+// Each rule is an action optionally followed by
+// "where" and a query expression (see CompileQuery):
+//
+//	set station_callsign=JJ1BDX where station_callsign = ''
+//	rename app_n1mm_exchange1 srx_string
+//	delete address email
+//	set mode=MFSK submode=FT4 where mode = FT4
+//
+// Values containing spaces can be quoted.
+// Rules are applied in order, and each condition is evaluated
+// against the record as modified by the preceding rules.
+
+// Errors
+var ErrTransformRule = errors.New("invalid transform rule")
+
+type fieldAssignment struct {
+	name  string
+	value string
+}
+
+// A transform rule
+type TransformRule struct {
+	// Action name: "set", "rename", or "delete"
+	action string
+	// Field assignments for "set"
+	assignments []fieldAssignment
+	// Field names for "rename" (old, new) and "delete"
+	names []string
+	// Condition (if nil, always applied)
+	condition RecordPredicate
+}
+
+// Parse a single transform rule
+func ParseTransformRule(line string) (*TransformRule, error) {
+	tokens, err := tokenizeQuery(line)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 || tokens[0].quoted {
+		return nil, fmt.Errorf("%w: missing action", ErrTransformRule)
+	}
+
+	// Split the action part and the condition part
+	actionTokens := tokens
+	var condTokens []queryToken
+	for i, tok := range tokens {
+		if !tok.quoted && strings.EqualFold(tok.text, "where") {
+			actionTokens = tokens[:i]
+			condTokens = tokens[i+1:]
+			break
+		}
+	}
+
+	rule := &TransformRule{}
+	rule.action = strings.ToLower(actionTokens[0].text)
+	args := actionTokens[1:]
+
+	switch rule.action {
+	case "set":
+		if len(args) == 0 || len(args)%3 != 0 {
+			return nil, fmt.Errorf("%w: set needs field=value pairs", ErrTransformRule)
+		}
+		for i := 0; i < len(args); i += 3 {
+			if args[i].quoted || args[i+1].quoted || args[i+1].text != "=" {
+				return nil, fmt.Errorf("%w: set needs field=value pairs", ErrTransformRule)
+			}
+			rule.assignments = append(rule.assignments, fieldAssignment{
+				name: strings.ToLower(args[i].text), value: args[i+2].text})
+		}
+	case "rename":
+		if len(args) != 2 {
+			return nil, fmt.Errorf("%w: rename needs two field names", ErrTransformRule)
+		}
+		rule.names = []string{strings.ToLower(args[0].text), strings.ToLower(args[1].text)}
+	case "delete":
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%w: delete needs field names", ErrTransformRule)
+		}
+		for _, arg := range args {
+			rule.names = append(rule.names, strings.ToLower(arg.text))
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown action %q", ErrTransformRule, rule.action)
+	}
+
+	if condTokens != nil {
+		if len(condTokens) == 0 {
+			return nil, fmt.Errorf("%w: empty condition", ErrTransformRule)
+		}
+		p := &queryParser{tokens: condTokens}
+		rule.condition, err = p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos < len(p.tokens) {
+			return nil, fmt.Errorf("%w: unexpected %q", ErrQuerySyntax, p.tokens[p.pos].text)
+		}
+	}
+	return rule, nil
+}
+
+// Load transform rules from a config file
+func LoadTransformRules(r io.Reader) ([]*TransformRule, error) {
+	rules := make([]*TransformRule, 0, 16)
+	scanner := bufio.NewScanner(r)
+	lineno := 0
+	for scanner.Scan() {
+		lineno++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := ParseTransformRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineno, err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Apply the rule to the record
+// Returns true if the condition matched
+func (rule *TransformRule) Apply(record ADIFRecord) bool {
+	if rule.condition != nil && !rule.condition(record) {
+		return false
+	}
+	switch rule.action {
+	case "set":
+		for _, a := range rule.assignments {
+			record.SetValue(a.name, a.value)
+		}
+	case "rename":
+		if v, err := record.GetValue(rule.names[0]); err == nil {
+			record.DeleteField(rule.names[0])
+			record.SetValue(rule.names[1], v)
+		}
+	case "delete":
+		for _, n := range rule.names {
+			record.DeleteField(n)
+		}
+	}
+	return true
+}
+
+// Apply all the rules in order to the record
+func ApplyTransformRules(rules []*TransformRule, record ADIFRecord) {
+	for _, rule := range rules {
+		rule.Apply(record)
+	}
+}
+
+// ADIFReader wrapper which transforms each record
+type transformADIFReader struct {
+	// Underlying reader
+	rdr ADIFReader
+	// Transform rules
+	rules []*TransformRule
+}
+
+// Create a new transforming reader
+func NewTransformADIFReader(r ADIFReader, rules []*TransformRule) *transformADIFReader {
+	reader := &transformADIFReader{}
+	reader.rdr = r
+	reader.rules = rules
+	return reader
+}
+
+func (ardr *transformADIFReader) ReadRecord() (ADIFRecord, error) {
+	record, err := ardr.rdr.ReadRecord()
+	if err != nil {
+		return nil, err
+	}
+	ApplyTransformRules(ardr.rules, record)
+	return record, nil
+}
+
+func (ardr *transformADIFReader) RecordCount() int {
+	return ardr.rdr.RecordCount()
+}
@@ -0,0 +1,64 @@
+package adifparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTransformRules(t *testing.T) {
+	rules, err := LoadTransformRules(strings.NewReader(`
+# Fill in the station callsign
+set station_callsign=JJ1BDX where station_callsign = ''
+rename app_n1mm_exchange1 srx_string
+set mode=MFSK submode=FT4 where mode = FT4
+delete address email
+set comment="worked on 20m" where band = 20m and not comment ~ .
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 5 {
+		t.Fatalf("Expected 5 rules, got %d", len(rules))
+	}
+
+	testData := "<call:5>KL3MM<mode:3>FT4<band:3>20m<app_n1mm_exchange1:2>05" +
+		"<address:3>xyz<email:3>a@b<eor>" +
+		"<call:4>W1AW<station_callsign:6>KF4MDV<mode:3>FT8<eor>"
+	reader := NewTransformADIFReader(
+		NewADIFReader(strings.NewReader(testData)), rules)
+
+	expected := []map[string]string{
+		{"call": "KL3MM", "station_callsign": "JJ1BDX", "mode": "MFSK",
+			"submode": "FT4", "band": "20m", "srx_string": "05",
+			"comment": "worked on 20m"},
+		{"call": "W1AW", "station_callsign": "KF4MDV", "mode": "FT8"},
+	}
+	for _, exp := range expected {
+		record, err := reader.ReadRecord()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(record.GetFields()) != len(exp) {
+			t.Fatalf("Expected %d fields, got %v", len(exp), record.GetFields())
+		}
+		for k, v := range exp {
+			if got, err := record.GetValue(k); err != nil || got != v {
+				t.Fatalf("Field %s: expected %q, got %q (%v)", k, v, got, err)
+			}
+		}
+	}
+}
+
+func TestTransformRuleErrors(t *testing.T) {
+	for _, line := range []string{
+		"frobnicate call", "set call", "set call JJ1BDX", "rename call",
+		"delete", "set call=X where", "set call=X where band ="} {
+		if _, err := ParseTransformRule(line); err == nil {
+			t.Fatalf("%q: expected an error", line)
+		}
+	}
+	_, err := LoadTransformRules(strings.NewReader("delete notes\nbogus\n"))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Fatalf("Expected an error for line 2, got %v", err)
+	}
+}
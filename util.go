@@ -2,6 +2,8 @@ package adifparser
 
 import (
 	"bytes"
+	"os"
+	"path/filepath"
 )
 
 // ASCII lowercase converter
@@ -90,3 +92,20 @@ func tagStartPos(b []byte) int {
 	}
 	return nextStart
 }
+
+// Write a file atomically with a temporary file and rename
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
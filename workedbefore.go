@@ -0,0 +1,293 @@
+package adifparser
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Worked/confirmed status of a call, an entity, a band slot or a grid
+type WorkedStatus int
+
+const (
+	NotWorked WorkedStatus = iota
+	Worked
+	Confirmed
+)
+
+// Status of a lookup without the fields given (e.g. DXCC of a QSO
+// before the entity is known)
+const Unknown WorkedStatus = -1
+
+func (s WorkedStatus) String() string {
+	switch s {
+	case Unknown:
+		return "unknown"
+	case NotWorked:
+		return "not worked"
+	case Worked:
+		return "worked"
+	case Confirmed:
+		return "confirmed"
+	}
+	return fmt.Sprintf("WorkedStatus(%d)", int(s))
+}
+
+// Whether the QSO is confirmed by QSL_RCVD or LOTW_QSL_RCVD
+// Y (yes) and V (verified) are confirmed
+func IsQSOConfirmed(r ADIFRecord) bool {
+	for _, name := range []string{"qsl_rcvd", "lotw_qsl_rcvd"} {
+		v, _ := r.GetValue(name)
+		switch strings.ToUpper(strings.TrimSpace(v)) {
+		case "Y", "V":
+			return true
+		}
+	}
+	return false
+}
+
+// Key of the worked-before maps
+type workedKey struct {
+	a, b, c string
+}
+
+// In-memory worked-before index
+// The lookups are map accesses, and the methods are safe for concurrent use
+type workedIndex struct {
+	mu           sync.RWMutex
+	calls        map[workedKey]WorkedStatus
+	callBandMode map[workedKey]WorkedStatus
+	dxcc         map[workedKey]WorkedStatus
+	dxccBand     map[workedKey]WorkedStatus
+	dxccMode     map[workedKey]WorkedStatus
+	grids        map[workedKey]WorkedStatus
+	gridBand     map[workedKey]WorkedStatus
+	records      int
+}
+
+// Create a new empty worked-before index
+func NewWorkedIndex() *workedIndex {
+	idx := &workedIndex{}
+	idx.calls = make(map[workedKey]WorkedStatus)
+	idx.callBandMode = make(map[workedKey]WorkedStatus)
+	idx.dxcc = make(map[workedKey]WorkedStatus)
+	idx.dxccBand = make(map[workedKey]WorkedStatus)
+	idx.dxccMode = make(map[workedKey]WorkedStatus)
+	idx.grids = make(map[workedKey]WorkedStatus)
+	idx.gridBand = make(map[workedKey]WorkedStatus)
+	return idx
+}
+
+// Build a worked-before index from all the records of the reader
+func BuildWorkedIndex(r ADIFReader) (*workedIndex, error) {
+	idx := NewWorkedIndex()
+	for record, err := range AllRecords(r) {
+		if err != nil {
+			return idx, err
+		}
+		idx.Add(record)
+	}
+	return idx, nil
+}
+
+// Normalized lookup keys
+func workedCall(call string) string {
+	return strings.ToUpper(strings.TrimSpace(call))
+}
+
+func workedBand(band string) string {
+	return strings.ToLower(strings.TrimSpace(band))
+}
+
+func workedMode(mode string) string {
+	return strings.ToUpper(strings.TrimSpace(mode))
+}
+
+func workedDXCC(dxcc string) string {
+	return DXCCCode(dxcc)
+}
+
+// 4-character grid square
+func workedGrid(grid string) string {
+	grid = strings.ToUpper(strings.TrimSpace(grid))
+	if len(grid) > 4 {
+		grid = grid[:4]
+	}
+	return grid
+}
+
+// Grid squares of a record: GRIDSQUARE and VUCC_GRIDS
+func recordGrids(r ADIFRecord) []string {
+	grids := []string{}
+	if g, err := r.GetValue("gridsquare"); err == nil && workedGrid(g) != "" {
+		grids = append(grids, workedGrid(g))
+	}
+	if v, err := r.GetValue("vucc_grids"); err == nil {
+		for _, g := range strings.Split(v, ",") {
+			if g = workedGrid(g); g != "" {
+				grids = append(grids, g)
+			}
+		}
+	}
+	return grids
+}
+
+func raiseStatus(m map[workedKey]WorkedStatus, key workedKey, status WorkedStatus) {
+	if m[key] < status {
+		m[key] = status
+	}
+}
+
+// Add a logged QSO
+func (idx *workedIndex) Add(r ADIFRecord) {
+	get := func(name string) string {
+		v, _ := r.GetValue(name)
+		return v
+	}
+	status := Worked
+	if IsQSOConfirmed(r) {
+		status = Confirmed
+	}
+	call := workedCall(get("call"))
+	band := workedBand(RecordBand(r))
+	mode := workedMode(get("mode"))
+	dxcc := workedDXCC(get("dxcc"))
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.records++
+	if call != "" {
+		raiseStatus(idx.calls, workedKey{a: call}, status)
+		raiseStatus(idx.callBandMode, workedKey{call, band, mode}, status)
+	}
+	if dxcc != "" {
+		raiseStatus(idx.dxcc, workedKey{a: dxcc}, status)
+		raiseStatus(idx.dxccBand, workedKey{a: dxcc, b: band}, status)
+		raiseStatus(idx.dxccMode, workedKey{a: dxcc, b: mode}, status)
+	}
+	for _, grid := range recordGrids(r) {
+		raiseStatus(idx.grids, workedKey{a: grid}, status)
+		raiseStatus(idx.gridBand, workedKey{a: grid, b: band}, status)
+	}
+}
+
+func (idx *workedIndex) lookup(m map[workedKey]WorkedStatus, key workedKey) WorkedStatus {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return m[key]
+}
+
+// Number of the QSOs added
+func (idx *workedIndex) RecordCount() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.records
+}
+
+// Status of the call on any band and mode
+func (idx *workedIndex) Call(call string) WorkedStatus {
+	return idx.lookup(idx.calls, workedKey{a: workedCall(call)})
+}
+
+// Status of the call on the band and mode
+func (idx *workedIndex) CallBandMode(call, band, mode string) WorkedStatus {
+	return idx.lookup(idx.callBandMode,
+		workedKey{workedCall(call), workedBand(band), workedMode(mode)})
+}
+
+// Status of the DXCC entity (e.g. "291")
+func (idx *workedIndex) DXCC(dxcc string) WorkedStatus {
+	return idx.lookup(idx.dxcc, workedKey{a: workedDXCC(dxcc)})
+}
+
+// Status of the DXCC band slot
+func (idx *workedIndex) DXCCBand(dxcc, band string) WorkedStatus {
+	return idx.lookup(idx.dxccBand, workedKey{a: workedDXCC(dxcc), b: workedBand(band)})
+}
+
+// Status of the DXCC entity on the mode
+func (idx *workedIndex) DXCCMode(dxcc, mode string) WorkedStatus {
+	return idx.lookup(idx.dxccMode, workedKey{a: workedDXCC(dxcc), b: workedMode(mode)})
+}
+
+// Status of the grid square (the first 4 characters are used)
+func (idx *workedIndex) Grid(grid string) WorkedStatus {
+	return idx.lookup(idx.grids, workedKey{a: workedGrid(grid)})
+}
+
+// Status of the grid square on the band
+func (idx *workedIndex) GridBand(grid, band string) WorkedStatus {
+	return idx.lookup(idx.gridBand, workedKey{a: workedGrid(grid), b: workedBand(band)})
+}
+
+// Worked-before status of a prospective QSO
+type WorkedResult struct {
+	Call         WorkedStatus
+	CallBandMode WorkedStatus
+	DXCC         WorkedStatus
+	DXCCBand     WorkedStatus
+	DXCCMode     WorkedStatus
+	// Lowest statuses of the grid squares (GRIDSQUARE and VUCC_GRIDS)
+	Grid     WorkedStatus
+	GridBand WorkedStatus
+}
+
+// Whether the QSO would be a new DXCC entity
+// The New methods are false for the Unknown statuses
+func (r WorkedResult) NewDXCC() bool {
+	return r.DXCC == NotWorked
+}
+
+// Whether the QSO would be a new DXCC band slot
+func (r WorkedResult) NewBandSlot() bool {
+	return r.DXCCBand == NotWorked
+}
+
+// Whether the QSO would be a new grid square (for any of the grids)
+func (r WorkedResult) NewGrid() bool {
+	return r.Grid == NotWorked
+}
+
+// Whether the call was worked before on the band and mode (a dupe)
+func (r WorkedResult) Dupe() bool {
+	return r.CallBandMode >= Worked
+}
+
+// Check a prospective QSO with CALL, BAND or FREQ, MODE, DXCC and GRIDSQUARE
+// Statuses needing the fields not given are Unknown
+func (idx *workedIndex) Check(r ADIFRecord) WorkedResult {
+	get := func(name string) string {
+		v, _ := r.GetValue(name)
+		return v
+	}
+	call, band, mode, dxcc := get("call"), RecordBand(r), get("mode"), get("dxcc")
+	hasBand, hasMode := workedBand(band) != "", workedMode(mode) != ""
+	result := WorkedResult{Unknown, Unknown, Unknown, Unknown, Unknown, Unknown, Unknown}
+	if workedCall(call) != "" {
+		result.Call = idx.Call(call)
+		if hasBand && hasMode {
+			result.CallBandMode = idx.CallBandMode(call, band, mode)
+		}
+	}
+	if workedDXCC(dxcc) != "" {
+		result.DXCC = idx.DXCC(dxcc)
+		if hasBand {
+			result.DXCCBand = idx.DXCCBand(dxcc, band)
+		}
+		if hasMode {
+			result.DXCCMode = idx.DXCCMode(dxcc, mode)
+		}
+	}
+	for i, grid := range recordGrids(r) {
+		if s := idx.Grid(grid); i == 0 || s < result.Grid {
+			result.Grid = s
+		}
+		if !hasBand {
+			continue
+		}
+		if s := idx.GridBand(grid, band); i == 0 || s < result.GridBand {
+			result.GridBand = s
+		}
+	}
+	return result
+}
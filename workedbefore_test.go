@@ -0,0 +1,108 @@
+package adifparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWorkedIndex(t *testing.T) {
+	testData := "<call:4>W1AW<band:3>20m<mode:2>CW<dxcc:3>291<gridsquare:6>FN31pr<eor>" +
+		"<call:4>W1AW<band:3>40m<mode:2>CW<dxcc:3>291<qsl_rcvd:1>Y<eor>" +
+		"<call:5>JA1XX<freq:6>14.074<mode:3>FT8<dxcc:3>339<gridsquare:4>PM95" +
+		"<lotw_qsl_rcvd:1>Y<eor>" +
+		"<call:4>K1XX<band:2>6m<mode:2>CW<dxcc:3>291<vucc_grids:9>FN42,FN43<eor>"
+	idx, err := BuildWorkedIndex(NewADIFReader(strings.NewReader(testData)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.RecordCount() != 4 {
+		t.Fatalf("Expected 4 records, got %d", idx.RecordCount())
+	}
+
+	for _, c := range []struct {
+		got, expected WorkedStatus
+	}{
+		{idx.Call("w1aw"), Confirmed},
+		{idx.CallBandMode("W1AW", "20M", "cw"), Worked},
+		{idx.CallBandMode("W1AW", "20m", "SSB"), NotWorked},
+		{idx.DXCC("291"), Confirmed},
+		{idx.DXCC("0339"), Confirmed},
+		{idx.DXCC("1"), NotWorked},
+		{idx.DXCCBand("291", "20m"), Worked},
+		{idx.DXCCBand("339", "20m"), Confirmed},
+		{idx.DXCCBand("339", "40m"), NotWorked},
+		{idx.DXCCMode("291", "CW"), Confirmed},
+		{idx.Grid("FN31"), Worked},
+		{idx.Grid("fn43aa"), Worked},
+		{idx.GridBand("FN42", "6m"), Worked},
+		{idx.GridBand("FN42", "2m"), NotWorked},
+	} {
+		if c.got != c.expected {
+			t.Fatalf("Expected %v, got %v", c.expected, c.got)
+		}
+	}
+
+	// Incremental update
+	qso := NewADIFRecord()
+	qso.SetValue("call", "W1AW")
+	qso.SetValue("band", "20m")
+	qso.SetValue("mode", "CW")
+	qso.SetValue("dxcc", "291")
+	qso.SetValue("gridsquare", "FN31")
+	result := idx.Check(qso)
+	if !result.Dupe() || result.NewDXCC() || result.NewBandSlot() || result.NewGrid() ||
+		result.DXCCBand != Worked {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+	qso.SetValue("qsl_rcvd", "V")
+	idx.Add(qso)
+	if s := idx.DXCCBand("291", "20m"); s != Confirmed {
+		t.Fatalf("Expected %v, got %v", Confirmed, s)
+	}
+
+	qso = NewADIFRecord()
+	qso.SetValue("call", "VK2XX")
+	qso.SetValue("freq", "14.074")
+	qso.SetValue("mode", "FT8")
+	qso.SetValue("dxcc", "150")
+	result = idx.Check(qso)
+	if result.Dupe() || !result.NewDXCC() || result.Call != NotWorked ||
+		result.Grid != Unknown || result.NewGrid() {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+
+	// Grid line: the grid not worked yet is new
+	qso = NewADIFRecord()
+	qso.SetValue("call", "W1XX")
+	qso.SetValue("band", "6m")
+	qso.SetValue("mode", "CW")
+	qso.SetValue("gridsquare", "FN42")
+	qso.SetValue("vucc_grids", "FN42,FN32")
+	result = idx.Check(qso)
+	if !result.NewGrid() || result.GridBand != NotWorked {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+	qso.SetValue("vucc_grids", "FN42,FN43")
+	result = idx.Check(qso)
+	if result.NewGrid() || result.Grid != Worked || result.GridBand != Worked {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+	qso.SetValue("band", "2m")
+	if result = idx.Check(qso); result.GridBand != NotWorked {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+
+	// DXCC and band not known yet
+	qso = NewADIFRecord()
+	qso.SetValue("call", "JA1ZZZ")
+	qso.SetValue("mode", "CW")
+	result = idx.Check(qso)
+	if result.NewDXCC() || result.NewBandSlot() || result.NewGrid() || result.Dupe() ||
+		result.DXCC != Unknown || result.DXCCBand != Unknown ||
+		result.CallBandMode != Unknown || result.Call != NotWorked {
+		t.Fatalf("Unexpected result %+v", result)
+	}
+	if Unknown.String() != "unknown" {
+		t.Fatalf("Unexpected string %s", Unknown)
+	}
+}